@@ -1,22 +1,57 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
-	"github.com/gammazero/workerpool"
+	"github.com/fatih/color"
 	"github.com/musana/cf-hero/internal/config"
-	"github.com/musana/cf-hero/internal/scanner"
+	"github.com/musana/cf-hero/internal/output"
 	"github.com/musana/cf-hero/internal/utils"
+	"github.com/musana/cf-hero/pkg/cfhero"
 )
 
 func main() {
-	fmt.Print(utils.Banner())
+	fmt.Fprint(os.Stderr, utils.Banner())
 
 	options := config.ParseOptions()
+
+	// In JSON mode stdout carries only findings, so everything meant for a
+	// human goes to stderr.
+	if options.JSON {
+		color.Output = os.Stderr
+	}
+
+	if options.UpdateRanges {
+		updated, err := cfhero.UpdateRanges(options.RangesURL, options.Proxy)
+		if err != nil {
+			color.Red("[-] Could not update Cloudflare IP ranges: %v", err)
+			os.Exit(1)
+		}
+		color.Green("[+] Cached %d IPv4 and %d IPv6 Cloudflare ranges in %s", len(updated.IPv4), len(updated.IPv6), updated.CachePath)
+		return
+	}
+
+	// Resolvers given on the command line take precedence over those in the
+	// config file.
+	resolvers := options.Resolvers
+	if len(resolvers) == 0 && options.ResolversFile == "" {
+		resolvers = config.ReadResolvers()
+	}
+
 	var urls []string
 	var domainList []string
 
+	if options.Candidates == "-" && options.File == "" && options.DomainList == "" {
+		fmt.Fprintln(color.Output, "[!] Candidates are read from the pipe, so urls must be given using f parameter!")
+		os.Exit(1)
+	}
+
 	if options.File != "" && options.DomainList == "" {
 		urls = utils.ReadFromFile(options.File)
 	} else if options.File == "" && options.DomainList != "" {
@@ -25,22 +60,111 @@ func main() {
 	} else {
 		fi, _ := os.Stdin.Stat()
 		if fi.Mode()&os.ModeNamedPipe == 0 {
-			fmt.Println("[!] No data found in pipe. Urls must be given using pipe or f parameter!")
+			fmt.Fprintln(color.Output, "[!] No data found in pipe. Urls must be given using pipe or f parameter!")
 			os.Exit(1)
 		} else {
 			urls = utils.ReadFromStdin()
 		}
 	}
 
-	scanner := scanner.New(options, urls, domainList)
-	scanner.PreScan()
+	var candidateList []cfhero.Candidate
+	if options.Candidates != "" {
+		var err error
+		candidateList, err = cfhero.ReadCandidates(options.Candidates, options.MaxCandidates)
+		if errors.Is(err, cfhero.ErrCandidateLimit) {
+			color.Yellow("[!] Candidate list expands to more than %d addresses. Only the first %d are checked; raise -max-candidates to check more.", options.MaxCandidates, options.MaxCandidates)
+		} else if err != nil {
+			color.Red("[-] Could not load candidate list: %v", err)
+			os.Exit(1)
+		}
+	}
+	if options.Import != "" {
+		imported, err := cfhero.ImportScan(options.Import)
+		if err != nil {
+			color.Red("[-] Could not import %s: %v", options.Import, err)
+			os.Exit(1)
+		}
+		color.White("[*] Imported %d host(s) with open ports from %s", len(imported), options.Import)
+		candidateList = append(candidateList, imported...)
+	}
 
-	wp := workerpool.New(options.Worker)
-	for _, url := range urls {
-		url := url
-		wp.Submit(func() {
-			scanner.Start(url)
-		})
+	hero, err := cfhero.New(cfhero.Options{
+		Workers:       options.Worker,
+		Verbose:       options.Verbose,
+		Sources:       options.Sources,
+		APIKeys:       config.ReadAllAPIKeys(),
+		Favicon:       options.Favicon,
+		FaviconPages:  options.FaviconPages,
+		Authoritative: options.Authoritative,
+		Brute:         options.Brute,
+		WordlistFile:  options.Wordlist,
+		SMTPBanner:    options.SMTPBanner,
+		SPFExpand:     options.SPFExpand,
+		Candidates:    candidateList,
+		Domains:       domainList,
+		Neighbors:     options.Neighbors,
+		MaxNeighbors:  options.MaxNeighbors,
+		Ports:         options.Ports,
+		CertPorts:     options.CertPorts,
+		Threshold:     options.Threshold,
+		Title:         options.Title,
+		HTTPMethod:    options.HTTPMethod,
+		UserAgent:     options.UserAgent,
+		JA3:           options.JA3,
+		Proxy:         options.Proxy,
+		Resolvers:     resolvers,
+		ResolversFile: options.ResolversFile,
+		DNSRetries:    options.DNSRetries,
+		DNSTimeout:    time.Duration(options.DNSTimeout) * time.Second,
+		RangesURL:     options.RangesURL,
+		Timeout:       options.Timeout,
+		TargetTimeout: options.TargetTimeout,
+		Resume:        options.Resume,
+	})
+	if err != nil {
+		color.Red("[-] %v", err)
+		os.Exit(1)
 	}
-	wp.StopWait()
+
+	// A resumed scan adds its findings to those of the previous runs.
+	writer, err := output.New(options.Output, options.JSON, hero.Resumed())
+	if err != nil {
+		fmt.Fprintf(color.Output, "[!] Could not open output file: %v\n", err)
+		os.Exit(1)
+	}
+	defer writer.Close()
+	hero.Subscribe(printEvent(writer))
+
+	ctx, cancel := interruptContext()
+	defer cancel()
+
+	if options.CF || options.NCF {
+		printDomains(ctx, hero, urls, options.Worker, options.Timeout, options.CF, options.NCF)
+		return
+	}
+
+	result, _ := hero.Scan(ctx, urls)
+	printSummary(result.Summary, options.Verbose)
+	if err := writer.Write(result.Summary); err != nil {
+		color.Red("[-] Error writing summary: %v", err)
+	}
+}
+
+// interruptContext returns a context that is cancelled on the first SIGINT or
+// SIGTERM, so the scan stops and still reports what it found. A second signal
+// terminates the process right away.
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		select {
+		case <-signals:
+			signal.Stop(signals)
+			color.Yellow("\n[!] Interrupted. Stopping the scan and writing partial results; press Ctrl-C again to quit immediately.")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
 }
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fatih/color"
+	"github.com/gammazero/workerpool"
+	"github.com/musana/cf-hero/internal/output"
+	"github.com/musana/cf-hero/pkg/cfhero"
+)
+
+// levelColors maps the level of a log event to the colour it is printed in.
+var levelColors = map[cfhero.Level]func(format string, a ...interface{}){
+	cfhero.LevelInfo:    color.White,
+	cfhero.LevelNotice:  color.Cyan,
+	cfhero.LevelSuccess: color.Green,
+	cfhero.LevelWarning: color.Yellow,
+	cfhero.LevelError:   color.Red,
+}
+
+// printEvent returns the event handler of the CLI: log events are printed in
+// the colour of their level, and findings are printed and written to the
+// JSON output.
+func printEvent(writer *output.Writer) func(cfhero.Event) {
+	return func(event cfhero.Event) {
+		switch event.Type {
+		case cfhero.EventLog:
+			levelColors[event.Level]("%s", event.Message)
+		case cfhero.EventFinding:
+			printFinding(*event.Finding)
+			if err := writer.Write(event.Finding); err != nil {
+				color.Red("[-] Error writing finding: %v", err)
+			}
+		}
+	}
+}
+
+func printFinding(finding cfhero.Finding) {
+	origin := finding.OriginIP
+	if finding.Port != 0 {
+		origin = fmt.Sprintf("%s (%s/%d)", finding.OriginIP, finding.Scheme, finding.Port)
+	}
+	color.Green("[+] Found real IP of %s : %s (Source: %s) - Title: %s - Score: %d (%s)",
+		finding.URL, origin, finding.Source, finding.Title, finding.Score, strings.Join(finding.Signals, ", "))
+	if len(finding.Evidence) > 0 {
+		color.Green("[+]   Evidence: %s", strings.Join(finding.Evidence, "; "))
+	}
+}
+
+// printSummary prints the final scan statistics.
+func printSummary(summary cfhero.Summary, verbose bool) {
+	if verbose {
+		color.White("\n[*] DNS cache: %d hit(s), %d miss(es).", summary.DNSCacheHits, summary.DNSCacheMisses)
+	}
+	color.White("\n[*] Scan finished. %d real IP(s) found out of %d IP(s) scanned.", summary.RealIPsFound, summary.TotalIPsScanned)
+	if summary.Unverifiable > 0 {
+		color.White("[*] %d target(s) could not be verified for lack of a usable baseline.", summary.Unverifiable)
+	}
+	if summary.TimedOut > 0 {
+		color.White("[*] %d target(s) timed out before every technique ran.", summary.TimedOut)
+	}
+
+	if summary.AXFRAttempted > 0 {
+		color.White("[*] Zone transfer succeeded on %d of %d nameserver(s) tried.", summary.AXFRSucceeded, summary.AXFRAttempted)
+		for _, transfer := range summary.ZoneTransfers {
+			if transfer.Success {
+				color.Green("[+]   %s transferred from %s (%d records)", transfer.Zone, transfer.Nameserver, transfer.Records)
+			}
+		}
+	}
+
+	if summary.Interrupted {
+		color.Yellow("[!] The scan was stopped before it finished. The results are partial.")
+	}
+}
+
+// printDomains prints the targets behind Cloudflare (-cf) or not (-non-cf)
+// as they were given. No target is looked up once ctx is done or timeout, when
+// set, has expired.
+func printDomains(ctx context.Context, hero *cfhero.Scanner, urls []string, workers int, timeout time.Duration, cf, ncf bool) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	wp := workerpool.New(workers)
+	for _, url := range urls {
+		if ctx.Err() != nil {
+			break
+		}
+		url := url
+		wp.Submit(func() {
+			cfIPs, nonCFIPs, err := hero.Lookup(ctx, url)
+			if err != nil {
+				return
+			}
+			if cf && len(cfIPs) > 0 {
+				fmt.Println(url)
+			}
+			if ncf && len(nonCFIPs) > 0 {
+				fmt.Println(url)
+			}
+		})
+	}
+	wp.StopWait()
+}
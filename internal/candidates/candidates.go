@@ -0,0 +1,94 @@
+package candidates
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/musana/cf-hero/internal/utils"
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// DefaultLimit is the number of addresses a candidate list may expand to
+// unless configured otherwise.
+const DefaultLimit = 65536
+
+// ErrLimit is returned along with the first addresses of a list that expands
+// to more than the limit.
+var ErrLimit = errors.New("candidate list exceeds the address limit")
+
+// Read returns the lines of the file at path, or of stdin when path is "-".
+func Read(path string) ([]string, error) {
+	if path == "-" {
+		return utils.ReadFromStdin(), nil
+	}
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	return utils.ReadFromFile(path), nil
+}
+
+// Expand parses a list of IP addresses and CIDR blocks, one per line, and
+// returns every address it covers as a "User supplied" candidate, without
+// duplicates. Blank lines and lines starting with '#' are skipped. At most
+// limit addresses are returned; when the list covers more, those found so far
+// are returned along with ErrLimit.
+func Expand(lines []string, limit int) ([]models.Candidate, error) {
+	var ips []models.Candidate
+	seen := make(map[string]bool)
+	add := func(ip net.IP) bool {
+		if seen[ip.String()] {
+			return true
+		}
+		if len(ips) >= limit {
+			return false
+		}
+		seen[ip.String()] = true
+		ips = append(ips, models.Candidate{IP: ip, Source: "User supplied"})
+		return true
+	}
+
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if !strings.Contains(line, "/") {
+			ip := net.ParseIP(line)
+			if ip == nil {
+				return ips, fmt.Errorf("line %d: invalid IP or CIDR %q", i+1, line)
+			}
+			if !add(ip) {
+				return ips, ErrLimit
+			}
+			continue
+		}
+
+		_, network, err := net.ParseCIDR(line)
+		if err != nil {
+			return ips, fmt.Errorf("line %d: invalid IP or CIDR %q", i+1, line)
+		}
+		for ip := append(net.IP{}, network.IP...); network.Contains(ip); ip = next(ip) {
+			if !add(ip) {
+				return ips, ErrLimit
+			}
+		}
+	}
+	return ips, nil
+}
+
+// next returns the address following ip. It wraps around to the zero address
+// after the last one, which ends iteration over any network but /0.
+func next(ip net.IP) net.IP {
+	ip = append(net.IP{}, ip...)
+	for i := len(ip) - 1; i >= 0; i-- {
+		ip[i]++
+		if ip[i] != 0 {
+			break
+		}
+	}
+	return ip
+}
@@ -0,0 +1,149 @@
+package candidates
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// Import reads an nmap XML (-oX) or masscan JSON (-oJ) report and returns
+// every host with at least one open TCP port as a candidate whose ports are
+// known to be open. The format is detected from the content.
+func Import(path string) ([]models.Candidate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	trimmed := bytes.TrimSpace(data)
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("<")):
+		return ParseNmap(bytes.NewReader(data))
+	case bytes.HasPrefix(trimmed, []byte("[")), bytes.HasPrefix(trimmed, []byte("{")):
+		return ParseMasscan(bytes.NewReader(data))
+	}
+	return nil, fmt.Errorf("%s is neither an nmap XML nor a masscan JSON report", path)
+}
+
+type nmapRun struct {
+	Hosts []struct {
+		Addresses []struct {
+			Addr     string `xml:"addr,attr"`
+			AddrType string `xml:"addrtype,attr"`
+		} `xml:"address"`
+		Ports []struct {
+			Protocol string `xml:"protocol,attr"`
+			PortID   int    `xml:"portid,attr"`
+			State    struct {
+				State string `xml:"state,attr"`
+			} `xml:"state"`
+		} `xml:"ports>port"`
+	} `xml:"host"`
+}
+
+// ParseNmap returns the hosts of an nmap XML report that have open TCP ports.
+func ParseNmap(r io.Reader) ([]models.Candidate, error) {
+	var run nmapRun
+	if err := xml.NewDecoder(r).Decode(&run); err != nil {
+		return nil, fmt.Errorf("invalid nmap XML: %v", err)
+	}
+
+	hosts := newHostSet("nmap")
+	for _, host := range run.Hosts {
+		var ip net.IP
+		for _, address := range host.Addresses {
+			if address.AddrType == "ipv4" || address.AddrType == "ipv6" {
+				ip = net.ParseIP(address.Addr)
+				break
+			}
+		}
+		if ip == nil {
+			continue
+		}
+		for _, port := range host.Ports {
+			if port.Protocol == "tcp" && port.State.State == "open" {
+				hosts.add(ip, port.PortID)
+			}
+		}
+	}
+	return hosts.candidates, nil
+}
+
+type masscanRecord struct {
+	IP    string `json:"ip"`
+	Ports []struct {
+		Port   int    `json:"port"`
+		Proto  string `json:"proto"`
+		Status string `json:"status"`
+	} `json:"ports"`
+}
+
+// ParseMasscan returns the hosts of a masscan JSON report that have open TCP
+// ports. masscan writes one record per line and, depending on its version,
+// leaves a trailing comma before the closing bracket, so records are decoded
+// line by line.
+func ParseMasscan(r io.Reader) ([]models.Candidate, error) {
+	hosts := newHostSet("masscan")
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSuffix(strings.TrimSpace(scanner.Text()), ",")
+		if line == "" || line == "[" || line == "]" || strings.HasPrefix(line, "{finished:") {
+			// older masscan versions end the report with an unquoted
+			// {finished: 1} trailer
+			continue
+		}
+		var record masscanRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("invalid masscan JSON on line %d: %v", n, err)
+		}
+		ip := net.ParseIP(record.IP)
+		if ip == nil {
+			// e.g. the {"finished": 1} trailer
+			continue
+		}
+		for _, port := range record.Ports {
+			if port.Proto == "tcp" && port.Status == "open" {
+				hosts.add(ip, port.Port)
+			}
+		}
+	}
+	return hosts.candidates, scanner.Err()
+}
+
+// hostSet collects open ports per address, in the order addresses first
+// appear.
+type hostSet struct {
+	source     string
+	index      map[string]int
+	candidates []models.Candidate
+}
+
+func newHostSet(source string) *hostSet {
+	return &hostSet{source: source, index: make(map[string]int)}
+}
+
+func (h *hostSet) add(ip net.IP, port int) {
+	if port < 1 || port > 65535 {
+		return
+	}
+	i, ok := h.index[ip.String()]
+	if !ok {
+		i = len(h.candidates)
+		h.index[ip.String()] = i
+		h.candidates = append(h.candidates, models.Candidate{IP: ip, Source: h.source, PortsOpen: true})
+	}
+	for _, existing := range h.candidates[i].Ports {
+		if existing == port {
+			return
+		}
+	}
+	h.candidates[i].Ports = append(h.candidates[i].Ports, port)
+}
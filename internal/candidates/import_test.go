@@ -0,0 +1,159 @@
+package candidates
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type imported struct {
+	ip    string
+	ports []int
+}
+
+func summarize(t *testing.T, source string, got []imported, err error, want []imported) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%s hosts = %v, want %v", source, got, want)
+	}
+}
+
+func TestParseNmap(t *testing.T) {
+	tests := []struct {
+		name   string
+		report string
+		want   []imported
+	}{
+		{
+			name: "open tcp ports only",
+			report: `<?xml version="1.0"?>
+<nmaprun>
+<host><status state="up"/>
+<address addr="192.0.2.10" addrtype="ipv4"/>
+<address addr="00:11:22:33:44:55" addrtype="mac"/>
+<ports>
+<port protocol="tcp" portid="443"><state state="open"/></port>
+<port protocol="tcp" portid="22"><state state="closed"/></port>
+<port protocol="udp" portid="53"><state state="open"/></port>
+<port protocol="tcp" portid="80"><state state="open"/></port>
+</ports>
+</host>
+</nmaprun>`,
+			want: []imported{{"192.0.2.10", []int{443, 80}}},
+		},
+		{
+			name: "mac address listed first",
+			report: `<nmaprun><host>
+<address addr="00:11:22:33:44:55" addrtype="mac"/>
+<address addr="2001:db8::1" addrtype="ipv6"/>
+<ports><port protocol="tcp" portid="8443"><state state="open"/></port></ports>
+</host></nmaprun>`,
+			want: []imported{{"2001:db8::1", []int{8443}}},
+		},
+		{
+			name: "hosts without open ports skipped",
+			report: `<nmaprun>
+<host><address addr="192.0.2.1" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="443"><state state="filtered"/></port></ports></host>
+<host><address addr="192.0.2.2" addrtype="ipv4"/></host>
+<host><address addr="192.0.2.3" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="443"><state state="open"/></port></ports></host>
+</nmaprun>`,
+			want: []imported{{"192.0.2.3", []int{443}}},
+		},
+		{
+			name: "same host merged",
+			report: `<nmaprun>
+<host><address addr="192.0.2.5" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="443"><state state="open"/></port></ports></host>
+<host><address addr="192.0.2.5" addrtype="ipv4"/>
+<ports><port protocol="tcp" portid="443"><state state="open"/></port>
+<port protocol="tcp" portid="8080"><state state="open"/></port></ports></host>
+</nmaprun>`,
+			want: []imported{{"192.0.2.5", []int{443, 8080}}},
+		},
+		{
+			name:   "empty run",
+			report: `<nmaprun></nmaprun>`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := ParseNmap(strings.NewReader(tt.report))
+			var got []imported
+			for _, c := range candidates {
+				if c.Source != "nmap" || !c.PortsOpen {
+					t.Errorf("candidate %s has source %q and PortsOpen %t", c.IP, c.Source, c.PortsOpen)
+				}
+				got = append(got, imported{c.IP.String(), c.Ports})
+			}
+			summarize(t, "nmap", got, err, tt.want)
+		})
+	}
+
+	if _, err := ParseNmap(strings.NewReader("<nmaprun><host>")); err == nil {
+		t.Error("ParseNmap accepted a truncated report")
+	}
+}
+
+func TestParseMasscan(t *testing.T) {
+	tests := []struct {
+		name   string
+		report string
+		want   []imported
+	}{
+		{
+			name: "trailing comma",
+			report: `[
+{   "ip": "192.0.2.10",   "timestamp": "1700000000", "ports": [ {"port": 443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 54} ] },
+{   "ip": "192.0.2.10",   "timestamp": "1700000001", "ports": [ {"port": 80, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 54} ] },
+{   "ip": "192.0.2.11",   "timestamp": "1700000002", "ports": [ {"port": 8443, "proto": "tcp", "status": "open", "reason": "syn-ack", "ttl": 54} ] },
+{"finished": 1}
+]`,
+			want: []imported{{"192.0.2.10", []int{443, 80}}, {"192.0.2.11", []int{8443}}},
+		},
+		{
+			name: "without trailing comma",
+			report: `[
+{"ip": "2001:db8::1", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]},
+{"ip": "192.0.2.20", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]},
+{finished: 1}
+]`,
+			want: []imported{{"2001:db8::1", []int{443}}, {"192.0.2.20", []int{443}}},
+		},
+		{
+			name: "udp, closed and out of range ports skipped",
+			report: `[
+{"ip": "192.0.2.30", "ports": [{"port": 53, "proto": "udp", "status": "open"}]},
+{"ip": "192.0.2.31", "ports": [{"port": 443, "proto": "tcp", "status": "closed"}]},
+{"ip": "192.0.2.32", "ports": [{"port": 0, "proto": "tcp", "status": "open"}, {"port": 443, "proto": "tcp", "status": "open"}]},
+{"ip": "192.0.2.32", "ports": [{"port": 443, "proto": "tcp", "status": "open"}]}
+]`,
+			want: []imported{{"192.0.2.32", []int{443}}},
+		},
+		{
+			name:   "empty report",
+			report: "[\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := ParseMasscan(strings.NewReader(tt.report))
+			var got []imported
+			for _, c := range candidates {
+				if c.Source != "masscan" || !c.PortsOpen {
+					t.Errorf("candidate %s has source %q and PortsOpen %t", c.IP, c.Source, c.PortsOpen)
+				}
+				got = append(got, imported{c.IP.String(), c.Ports})
+			}
+			summarize(t, "masscan", got, err, tt.want)
+		})
+	}
+
+	if _, err := ParseMasscan(strings.NewReader("[\n{\"ip\": \"192.0.2.1\", \"ports\": [\n]")); err == nil {
+		t.Error("ParseMasscan accepted a malformed line")
+	}
+}
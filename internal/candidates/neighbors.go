@@ -0,0 +1,64 @@
+package candidates
+
+import (
+	"math/big"
+	"net"
+)
+
+// Neighbors returns the addresses that share ip's IPv4 /prefix, closest to
+// ip first and excluding ip itself, up to limit addresses. IPv6 addresses
+// use a network with as many host bits, e.g. a /120 for a prefix of 24. The
+// network and broadcast addresses of an IPv4 network are left out, except in
+// /31 and /32 networks, which have none.
+func Neighbors(ip net.IP, prefix, limit int) []net.IP {
+	bits := 32
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	} else {
+		bits = 128
+		ip = ip.To16()
+	}
+	if prefix < 0 || prefix > 32 {
+		return nil
+	}
+	network := &net.IPNet{IP: ip.Mask(net.CIDRMask(bits-32+prefix, bits)), Mask: net.CIDRMask(bits-32+prefix, bits)}
+	var broadcast net.IP
+	if bits == 32 && prefix < 31 {
+		broadcast = make(net.IP, len(network.IP))
+		for i := range broadcast {
+			broadcast[i] = network.IP[i] | ^network.Mask[i]
+		}
+	}
+
+	origin := new(big.Int).SetBytes(ip)
+	var neighbors []net.IP
+	for distance := int64(1); len(neighbors) < limit; distance++ {
+		found := false
+		for _, delta := range []int64{distance, -distance} {
+			neighbor := toIP(new(big.Int).Add(origin, big.NewInt(delta)), len(ip))
+			if neighbor == nil || !network.Contains(neighbor) {
+				continue
+			}
+			found = true
+			if broadcast != nil && (neighbor.Equal(network.IP) || neighbor.Equal(broadcast)) {
+				continue
+			}
+			if len(neighbors) < limit {
+				neighbors = append(neighbors, neighbor)
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	return neighbors
+}
+
+// toIP converts n back to an address of size bytes, or returns nil when it is
+// out of range.
+func toIP(n *big.Int, size int) net.IP {
+	if n.Sign() < 0 || n.BitLen() > size*8 {
+		return nil
+	}
+	return n.FillBytes(make(net.IP, size))
+}
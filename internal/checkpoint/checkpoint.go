@@ -0,0 +1,322 @@
+// Package checkpoint records the progress of a scan in a file so that an
+// interrupted run can be resumed: completed targets and techniques are
+// skipped, and the candidates passive sources returned are reused instead of
+// querying the APIs again.
+//
+// The file is a journal with one JSON entry per line. Every change appends
+// one entry, so recording progress costs the same however large the scan
+// grows, and Open replays the entries to rebuild the state.
+package checkpoint
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// version is the format of the checkpoint file.
+const version = 1
+
+// Entry operations.
+const (
+	opHeader     = "checkpoint"
+	opTargetDone = "target_done"
+	opSourceDone = "source_done"
+	opCandidates = "candidates"
+	opOrigin     = "origin"
+)
+
+// Checkpoint is the progress of a scan, appended to its file on every
+// change. It is safe for concurrent use. A nil Checkpoint records nothing.
+type Checkpoint struct {
+	mu      sync.Mutex
+	path    string
+	file    *os.File
+	targets map[string]*targetState
+}
+
+type targetState struct {
+	// done holds the techniques the target was completed with, empty while
+	// it is not.
+	done string
+	// completed holds the verification settings each technique and source
+	// ran to completion with.
+	completed map[string]string
+	// candidates holds the candidates of passive sources, by source name.
+	candidates map[string][]models.Candidate
+	// origins are the confirmed origins of the target, except those found
+	// among the neighbors of another. They seed the neighbor ranges again
+	// when the target is resumed.
+	origins []string
+}
+
+// entry is one line of the journal.
+type entry struct {
+	Op         string             `json:"op"`
+	Version    int                `json:"version,omitempty"`
+	Target     string             `json:"target,omitempty"`
+	Name       string             `json:"name,omitempty"`
+	Techniques string             `json:"techniques,omitempty"`
+	Settings   string             `json:"settings,omitempty"`
+	IP         string             `json:"ip,omitempty"`
+	Candidates []models.Candidate `json:"candidates,omitempty"`
+}
+
+// Open replays the checkpoint file at path, or starts an empty checkpoint
+// when it does not exist yet. An entry cut short by a crash at the end of the
+// file is dropped.
+func Open(path string) (*Checkpoint, error) {
+	c := &Checkpoint{path: path, targets: make(map[string]*targetState)}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	var valid int64
+	for n := 1; ; n++ {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(line) > 0 {
+				// Every entry ends with a newline, so this one was being
+				// written when the scan stopped. Drop it so new entries
+				// start on a fresh line.
+				if err := os.Truncate(path, valid); err != nil {
+					return nil, err
+				}
+			}
+			return c, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		valid += int64(len(line))
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var e entry
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, fmt.Errorf("invalid checkpoint file %s: line %d: %v", path, n, err)
+		}
+		if n == 1 && (e.Op != opHeader || e.Version != version) {
+			return nil, fmt.Errorf("checkpoint file %s has an unsupported format", path)
+		}
+		c.apply(e)
+	}
+}
+
+// Close closes the checkpoint file. Recording more progress opens it again.
+func (c *Checkpoint) Close() error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.file == nil {
+		return nil
+	}
+	err := c.file.Close()
+	c.file = nil
+	return err
+}
+
+// Resumed reports whether the checkpoint holds progress from a previous run.
+func (c *Checkpoint) Resumed() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.targets) > 0
+}
+
+// TargetDone returns the techniques target was completed with, or "" when it
+// was not. A target completed with other techniques is scanned again,
+// skipping the techniques it already completed with the same settings.
+func (c *Checkpoint) TargetDone(target string) string {
+	if c == nil {
+		return ""
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t := c.targets[target]; t != nil {
+		return t.done
+	}
+	return ""
+}
+
+// MarkTargetDone records that target was completed with the given
+// techniques.
+func (c *Checkpoint) MarkTargetDone(target, techniques string) error {
+	return c.record(entry{Op: opTargetDone, Target: target, Techniques: techniques})
+}
+
+// SourceDone reports whether the technique or source name ran to completion
+// against target with the given verification settings.
+func (c *Checkpoint) SourceDone(target, name, settings string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.targets[target]
+	if t == nil {
+		return false
+	}
+	done, ok := t.completed[name]
+	return ok && done == settings
+}
+
+// MarkSourceDone records that the technique or source name ran to
+// completion against target with the given verification settings.
+func (c *Checkpoint) MarkSourceDone(target, name, settings string) error {
+	return c.record(entry{Op: opSourceDone, Target: target, Name: name, Settings: settings})
+}
+
+// Candidates returns the candidates the passive source name returned for
+// target in a previous run; ok is false when none were saved.
+func (c *Checkpoint) Candidates(target, name string) (candidates []models.Candidate, ok bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := c.targets[target]
+	if t == nil {
+		return nil, false
+	}
+	candidates, ok = t.candidates[name]
+	return candidates, ok
+}
+
+// SaveCandidates records every candidate the passive source name returned for
+// target.
+func (c *Checkpoint) SaveCandidates(target, name string, candidates []models.Candidate) error {
+	candidates = append([]models.Candidate{}, candidates...)
+	return c.record(entry{Op: opCandidates, Target: target, Name: name, Candidates: candidates})
+}
+
+// Origins returns the confirmed origins of target.
+func (c *Checkpoint) Origins(target string) []string {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t := c.targets[target]; t != nil {
+		return append([]string{}, t.origins...)
+	}
+	return nil
+}
+
+// AddOrigin records a confirmed origin of target.
+func (c *Checkpoint) AddOrigin(target, ip string) error {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	t := c.targets[target]
+	if t != nil {
+		for _, origin := range t.origins {
+			if origin == ip {
+				c.mu.Unlock()
+				return nil
+			}
+		}
+	}
+	c.mu.Unlock()
+	return c.record(entry{Op: opOrigin, Target: target, IP: ip})
+}
+
+// record applies e and appends it to the journal.
+func (c *Checkpoint) record(e entry) error {
+	if c == nil {
+		return nil
+	}
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.apply(e)
+	if c.file == nil {
+		if err := c.open(); err != nil {
+			return err
+		}
+	}
+	_, err = c.file.Write(line)
+	return err
+}
+
+// open opens the journal for appending, writing its header when the file is
+// new.
+func (c *Checkpoint) open() error {
+	file, err := os.OpenFile(c.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err == nil && info.Size() == 0 {
+		var header []byte
+		header, err = json.Marshal(entry{Op: opHeader, Version: version})
+		if err == nil {
+			_, err = file.Write(append(header, '\n'))
+		}
+	}
+	if err != nil {
+		file.Close()
+		return err
+	}
+	c.file = file
+	return nil
+}
+
+// apply updates the state with e. The caller holds c.mu, except in Open.
+func (c *Checkpoint) apply(e entry) {
+	if e.Op == opHeader {
+		return
+	}
+	t := c.targets[e.Target]
+	if t == nil {
+		t = &targetState{}
+		c.targets[e.Target] = t
+	}
+	switch e.Op {
+	case opTargetDone:
+		t.done = e.Techniques
+	case opSourceDone:
+		if t.completed == nil {
+			t.completed = make(map[string]string)
+		}
+		t.completed[e.Name] = e.Settings
+	case opCandidates:
+		// The name is recorded even without candidates, so a source that
+		// returned none is not queried again.
+		if t.candidates == nil {
+			t.candidates = make(map[string][]models.Candidate)
+		}
+		t.candidates[e.Name] = e.Candidates
+	case opOrigin:
+		for _, origin := range t.origins {
+			if origin == e.IP {
+				return
+			}
+		}
+		t.origins = append(t.origins, e.IP)
+	}
+}
@@ -4,8 +4,17 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
+	"github.com/fatih/color"
+	"github.com/musana/cf-hero/internal/candidates"
+	"github.com/musana/cf-hero/internal/dns"
+	httpClient "github.com/musana/cf-hero/internal/http"
+	"github.com/musana/cf-hero/internal/ranges"
+	"github.com/musana/cf-hero/internal/sources"
+	"github.com/musana/cf-hero/internal/verify"
 	"github.com/musana/cf-hero/pkg/models"
 	"github.com/projectdiscovery/goflags"
 	"gopkg.in/yaml.v2"
@@ -13,38 +22,92 @@ import (
 
 func ParseOptions() *models.Options {
 	options := &models.Options{}
+	var certPorts, ports, resolvers goflags.StringSlice
 	flagSet := goflags.NewFlagSet()
-	flagSet.SetDescription(`Unmask the origin IPs of Cloudflare-protected domains`)
+	flagSet.SetDescription(`Unmask the origin IPs of Cloudflare-protected domains
+
+Commands:
+   update-ranges  Download the Cloudflare IP ranges and refresh the local cache`)
+
+	// Subcommands come before the flags; strip them so goflags only sees flags.
+	if len(os.Args) > 1 && os.Args[1] == "update-ranges" {
+		options.UpdateRanges = true
+		os.Args = append(os.Args[:1], os.Args[2:]...)
+	}
 
 	createGroup(flagSet, "General Options", "GENERAL OPTIONS",
 		flagSet.IntVar(&options.Worker, "w", 16, "Worker count"),
 		flagSet.StringVar(&options.File, "f", "", "Input file containing list of host/domain"),
 		flagSet.BoolVar(&options.Verbose, "v", false, "Enable verbose output"),
 		flagSet.StringVar(&options.Title, "title", "", "Specify HTML title to match (skip fetching from Cloudflare domain)"),
+		flagSet.IntVar(&options.Threshold, "threshold", verify.DefaultThreshold, "Minimum origin similarity score (0-100) to report a finding"),
 	)
 
 	createGroup(flagSet, "print options", "PRINT OPTIONS",
 		flagSet.BoolVar(&options.CF, "cf", false, "Print domains behind Cloudflare"),
 		flagSet.BoolVar(&options.NCF, "non-cf", false, "Print domains not behind Cloudflare"),
+		flagSet.BoolVar(&options.JSON, "json", false, "Write findings to stdout as JSON lines (other output goes to stderr)"),
+		flagSet.StringVar(&options.Output, "o", "", "File to write findings to as JSON lines"),
 	)
 
-	createGroup(flagSet, "sources", "SOURCES",
-		flagSet.BoolVar(&options.Censys, "censys", false, "Include Censys in scanning"),
-		flagSet.BoolVar(&options.SecurityTrails, "securitytrails", false, "Include SecurityTrails historical DNS records in scanning"),
-		flagSet.BoolVar(&options.Shodan, "shodan", false, "Include Shodan historical DNS records in scanning"),
-		flagSet.BoolVar(&options.Zoomeye, "zoomeye", false, "Include Zoomeye in scanning"),
+	// One flag per registered source, named after its ID.
+	registered := sources.All(nil)
+	enabled := make([]bool, len(registered))
+	var sourceFlags []*goflags.FlagData
+	var faviconSources []string
+	for i, source := range registered {
+		sourceFlags = append(sourceFlags, flagSet.BoolVar(&enabled[i], source.ID(), false, source.Description()))
+		if _, ok := source.(sources.FaviconSource); ok {
+			faviconSources = append(faviconSources, source.Name())
+		}
+	}
+	sourceFlags = append(sourceFlags,
+		flagSet.BoolVar(&options.Favicon, "favicon", false, fmt.Sprintf("Search enabled sources (%s) for hosts serving the target's favicon", strings.Join(faviconSources, ", "))),
+		flagSet.IntVar(&options.FaviconPages, "favicon-pages", sources.DefaultFaviconPages, "Maximum result pages fetched from each source per favicon search (each page may cost an API credit)"),
+		flagSet.BoolVar(&options.Authoritative, "authoritative", false, "Query the zone's authoritative nameservers directly and check records they disagree on"),
+		flagSet.BoolVar(&options.Brute, "brute", false, "Brute force origin-hint subdomains (origin., direct., mail., dev., ...) with the built-in wordlist"),
+		flagSet.StringVar(&options.Wordlist, "wordlist", "", "Wordlist for subdomain brute force (implies -brute)"),
+		flagSet.BoolVar(&options.SMTPBanner, "smtp-banner", false, "Record the hostname in the SMTP greeting of MX candidates as evidence"),
+		flagSet.IntVar(&options.SPFExpand, "spf-expand", 0, "Expand SPF ip4/ip6 blocks of at most this many addresses into candidates (e.g. 256 for a /24)"),
+		flagSet.StringVar(&options.Candidates, "candidates", "", "File of candidate IPs and CIDRs to verify against every target ('-' for stdin)"),
+		flagSet.StringVar(&options.Import, "import", "", "nmap XML (-oX) or masscan JSON (-oJ) report whose open ports are verified against every target"),
+		flagSet.IntVar(&options.MaxCandidates, "max-candidates", candidates.DefaultLimit, "Maximum number of addresses the candidate list may expand to"),
+		flagSet.IntVar(&options.Neighbors, "neighbors", 0, "Verify the addresses around each confirmed origin within this IPv4 prefix length (e.g. 24 or 28)"),
+		flagSet.IntVar(&options.MaxNeighbors, "max-neighbors", 256, "Maximum number of neighbors verified per confirmed origin"),
 		flagSet.StringVar(&options.DomainList, "dl", "", "Domain list for sub/domain scanning"),
 		flagSet.StringVar(&options.TargetDomain, "td", "", "Target domain for sub/domain scanning"),
 	)
+	createGroup(flagSet, "sources", "SOURCES", sourceFlags...)
 
 	createGroup(flagSet, "configuration", "CONFIGURATION",
 		flagSet.StringVar(&options.HTTPMethod, "hm", "GET", "HTTP method."),
 		flagSet.StringVar(&options.JA3, "ja3", "772,4865-4866-4867-49195-49199-49196-49200-52393-52392-49171-49172-156-157-47-53,18-10-16-23-45-35-5-11-13-65281-0-51-43-17513-27,29-23-24,0", "JA3 String"),
 		flagSet.StringVar(&options.UserAgent, "ua", "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:109.0) Gecko/20100101 Firefox/113.0", "HTTP User-Agent"),
 		flagSet.StringVar(&options.Proxy, "px", "", "HTTP proxy URL"),
+		flagSet.StringSliceVar(&resolvers, "resolvers", nil, "DNS resolvers (e.g. 1.1.1.1, tcp:9.9.9.9:53, tls://1.1.1.1, https://cloudflare-dns.com/dns-query)", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringVar(&options.ResolversFile, "resolvers-file", "", "File containing DNS resolvers, one per line"),
+		flagSet.IntVar(&options.DNSRetries, "dns-retries", dns.DefaultRetries, "Attempts per DNS query, each against the next resolver"),
+		flagSet.IntVar(&options.DNSTimeout, "dns-timeout", int(dns.DefaultTimeout/time.Second), "Timeout of a DNS query attempt in seconds"),
+		flagSet.DurationVar(&options.Timeout, "timeout", 0, "Stop the scan after this long and report the partial results (e.g. 30m; 0 for no limit)"),
+		flagSet.DurationVar(&options.TargetTimeout, "target-timeout", 0, "Skip the remaining techniques of a target after this long (e.g. 5m; 0 for no limit)"),
+		flagSet.StringVar(&options.Resume, "resume", "", "Checkpoint file recording the scan's progress; rerun with the same file to skip completed work and reuse source responses"),
+		flagSet.StringVar(&options.RangesURL, "ranges-url", ranges.DefaultURL, "Base URL of the Cloudflare ips-v4/ips-v6 lists"),
+		flagSet.StringSliceVar(&ports, "ports", httpClient.DefaultPorts, "Candidate ports to probe (e.g. 80,443,8080,https:8443); prefix with http: or https: to skip scheme detection", goflags.CommaSeparatedStringSliceOptions),
+		flagSet.StringSliceVar(&certPorts, "cert-ports", nil, "Extra TLS ports to fetch candidate certificates from (443 is always checked)", goflags.CommaSeparatedStringSliceOptions),
 	)
 
 	_ = flagSet.Parse()
+	options.CertPorts = certPorts
+	options.Ports = ports
+	options.Resolvers = resolvers
+	if options.Wordlist != "" {
+		options.Brute = true
+	}
+	for i, source := range registered {
+		if enabled[i] {
+			options.Sources = append(options.Sources, source.ID())
+		}
+	}
 
 	return options
 }
@@ -77,20 +140,26 @@ func loadAPIKeys() {
 		path := configPath()
 		f, err := os.ReadFile(path)
 		if err != nil {
-			fmt.Printf("[!] Error reading config file %s: %v\n", path, err)
+			fmt.Fprintf(color.Output, "[!] Error reading config file %s: %v\n", path, err)
 			return
 		}
 
 		if err := yaml.Unmarshal(f, &apiKeys); err != nil {
-			fmt.Printf("[!] Error parsing YAML from %s: %v\n", path, err)
+			fmt.Fprintf(color.Output, "[!] Error parsing YAML from %s: %v\n", path, err)
 			apiKeys = nil
 		}
 	})
 }
 
-// ReadAPIKeys returns the configured API keys for the given source, or nil if
-// none are configured. The config file is loaded only once and cached.
-func ReadAPIKeys(source string) []string {
+// ReadAllAPIKeys returns every entry of the config file, keyed by name.
+func ReadAllAPIKeys() map[string][]string {
+	loadAPIKeys()
+	return apiKeys
+}
+
+// ReadResolvers returns the DNS resolvers listed under "resolvers" in the
+// config file, or nil if none are configured.
+func ReadResolvers() []string {
 	loadAPIKeys()
-	return apiKeys[source]
+	return apiKeys["resolvers"]
 }
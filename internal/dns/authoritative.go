@@ -0,0 +1,243 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/miekg/dns"
+	"github.com/projectdiscovery/retryabledns"
+)
+
+// authoritativeNames are the subdomains queried on every authoritative
+// server in addition to the apex.
+var authoritativeNames = []string{"www", "origin", "direct", "mail", "ftp", "cpanel", "api", "dev", "staging"}
+
+// authoritativeTypes are the record types compared across servers.
+var authoritativeTypes = []uint16{dns.TypeA, dns.TypeAAAA, dns.TypeMX, dns.TypeTXT}
+
+// Nameserver is an authoritative server of a zone.
+type Nameserver struct {
+	Host string
+	IP   net.IP
+}
+
+// nameserverPort is the port authoritative nameservers are queried on.
+var nameserverPort = "53"
+
+// AuthoritativeAddress is an address that not every server agrees on: some
+// authoritative servers return it and others, or the recursive resolvers,
+// do not.
+type AuthoritativeAddress struct {
+	IP net.IP
+	// Record is the queried name and type, e.g. "A origin.example.com".
+	Record string
+	// Nameservers lists the authoritative servers that returned it.
+	Nameservers []string
+	// Reason describes the disagreement.
+	Reason string
+}
+
+// GetNameservers returns the authoritative servers of the zone domain belongs
+// to, walking up the name until an NS set is found.
+func GetNameservers(ctx context.Context, domain string) (zone string, nameservers []Nameserver, err error) {
+	client, err := newClient()
+	if err != nil {
+		return "", nil, err
+	}
+
+	labels := strings.Split(strings.TrimSuffix(domain, "."), ".")
+	for i := 0; i < len(labels)-1; i++ {
+		if err := ctx.Err(); err != nil {
+			return "", nil, err
+		}
+		zone = strings.Join(labels[i:], ".")
+		data, err := client.Query(zone, dns.TypeNS)
+		if err != nil || len(data.NS) == 0 {
+			continue
+		}
+		for _, host := range data.NS {
+			for _, ip := range resolve(ctx, host) {
+				nameservers = append(nameservers, Nameserver{Host: host, IP: ip})
+			}
+		}
+		return zone, nameservers, nil
+	}
+	return "", nil, fmt.Errorf("no NS records found for %s", domain)
+}
+
+// CompareAuthoritative asks every nameserver directly for the A, AAAA, MX and
+// TXT records of domain and of common subdomains, and returns the addresses
+// that are missing from at least one authoritative server or from the
+// recursive resolvers' answers. Once ctx is done no more queries are sent
+// and ctx's error is returned.
+func CompareAuthoritative(ctx context.Context, domain string, nameservers []Nameserver) ([]AuthoritativeAddress, error) {
+	recursive, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+
+	names := []string{domain}
+	for _, label := range authoritativeNames {
+		names = append(names, label+"."+domain)
+	}
+
+	// answers[server][record] holds the addresses a server returned.
+	answers := make([]map[string][]string, len(nameservers))
+	var wg sync.WaitGroup
+	for i, ns := range nameservers {
+		wg.Add(1)
+		go func(i int, ns Nameserver) {
+			defer wg.Done()
+			answers[i] = make(map[string][]string)
+			client, err := retryabledns.NewWithOptions(retryabledns.Options{
+				BaseResolvers: []string{"udp:" + net.JoinHostPort(ns.IP.String(), nameserverPort)},
+				MaxRetries:    retries,
+				Timeout:       timeout,
+			})
+			if err != nil {
+				return
+			}
+			client.TCPFallback = true
+			// Skip servers that are unreachable (e.g. over IPv6) rather
+			// than timing out on every query.
+			if _, err := client.Query(domain, dns.TypeSOA); err != nil {
+				return
+			}
+			for _, name := range names {
+				for _, qtype := range authoritativeTypes {
+					if addresses, ok := recordAddresses(ctx, client, recursive, name, qtype); ok {
+						answers[i][recordKey(name, qtype)] = addresses
+					}
+				}
+			}
+		}(i, ns)
+	}
+	wg.Wait()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var addresses []AuthoritativeAddress
+	for _, name := range names {
+		for _, qtype := range authoritativeTypes {
+			key := recordKey(name, qtype)
+			recursiveAddresses, recursiveOK := recordAddresses(ctx, recursive, recursive, name, qtype)
+			recursiveSet := toSet(recursiveAddresses)
+
+			servers := make(map[string][]string)
+			var order []string
+			for i, ns := range nameservers {
+				for _, address := range answers[i][key] {
+					if _, ok := servers[address]; !ok {
+						order = append(order, address)
+					}
+					servers[address] = appendUnique(servers[address], ns.Host)
+				}
+			}
+
+			for _, address := range order {
+				var reasons []string
+				if missing := missingFrom(nameservers, answers, key, address); len(missing) > 0 {
+					reasons = append(reasons, "not returned by "+strings.Join(missing, ", "))
+				}
+				if recursiveOK && !recursiveSet[address] {
+					reasons = append(reasons, "not returned by recursive resolvers")
+				}
+				if len(reasons) == 0 {
+					continue
+				}
+				addresses = append(addresses, AuthoritativeAddress{
+					IP:          net.ParseIP(address),
+					Record:      key,
+					Nameservers: servers[address],
+					Reason:      strings.Join(reasons, "; "),
+				})
+			}
+		}
+	}
+	return addresses, nil
+}
+
+// recordAddresses returns the addresses carried by a record set as client
+// sees it; ok is false when the server gave no answer. MX hosts are resolved
+// through the recursive client, since they may live outside the zone; TXT
+// records contribute the single addresses of their ip4: and ip6: mechanisms.
+func recordAddresses(ctx context.Context, client, recursive *retryabledns.Client, name string, qtype uint16) (addresses []string, ok bool) {
+	if ctx.Err() != nil {
+		return nil, false
+	}
+	switch qtype {
+	case dns.TypeA, dns.TypeAAAA:
+		data, err := client.Query(name, qtype)
+		if err != nil {
+			return nil, false
+		}
+		addresses = append(data.A, data.AAAA...)
+	case dns.TypeMX:
+		data, err := client.Query(name, qtype)
+		if err != nil {
+			return nil, false
+		}
+		for _, host := range data.MX {
+			for _, ip := range resolve(ctx, host) {
+				addresses = append(addresses, ip.String())
+			}
+		}
+	case dns.TypeTXT:
+		records, err := txtRecords(ctx, client, name)
+		if err != nil {
+			return nil, false
+		}
+		for _, record := range records {
+			for _, field := range strings.Fields(record) {
+				lower := strings.ToLower(strings.TrimLeft(field, "+-~?"))
+				if !strings.HasPrefix(lower, "ip4:") && !strings.HasPrefix(lower, "ip6:") {
+					continue
+				}
+				if ip := net.ParseIP(lower[len("ip4:"):]); ip != nil {
+					addresses = append(addresses, ip.String())
+				}
+			}
+		}
+	}
+	sort.Strings(addresses)
+	return addresses, true
+}
+
+func recordKey(name string, qtype uint16) string {
+	return dns.TypeToString[qtype] + " " + name
+}
+
+// missingFrom returns the servers whose answer for key lacks address.
+// Servers that did not answer at all are not counted.
+func missingFrom(nameservers []Nameserver, answers []map[string][]string, key, address string) []string {
+	var missing []string
+	for i, ns := range nameservers {
+		answer, answered := answers[i][key]
+		if answered && !toSet(answer)[address] {
+			missing = appendUnique(missing, ns.Host)
+		}
+	}
+	return missing
+}
+
+func toSet(list []string) map[string]bool {
+	set := make(map[string]bool, len(list))
+	for _, item := range list {
+		set[item] = true
+	}
+	return set
+}
+
+func appendUnique(list []string, item string) []string {
+	for _, existing := range list {
+		if existing == item {
+			return list
+		}
+	}
+	return append(list, item)
+}
@@ -0,0 +1,156 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/miekg/dns"
+)
+
+const (
+	// maxTransferHosts caps the record targets resolved from a single zone
+	// transfer.
+	maxTransferHosts = 5000
+	// transferConcurrency is the number of parallel lookups used to resolve
+	// the hosts of a transferred zone.
+	transferConcurrency = 20
+)
+
+// ZoneTransfer is the outcome of an AXFR request to one nameserver.
+type ZoneTransfer struct {
+	Zone       string
+	Nameserver string
+	// Records is the number of records received; zero when the transfer
+	// was refused.
+	Records int
+	// Hosts are the names found in the zone with their addresses: those the
+	// zone holds A/AAAA records for, as transferred, then the names its
+	// CNAME, MX and SRV records point at, as resolved.
+	Hosts []Subdomain
+	Err   error
+}
+
+// TransferZone requests a full zone transfer of zone from ns. On success the
+// addresses in the zone are returned as they were transferred, since the
+// recursive resolvers only know the proxied ones, if any. The names CNAME, MX
+// and SRV records point at are resolved unless the zone holds their
+// addresses. The transfer is aborted when ctx is done.
+func TransferZone(ctx context.Context, zone string, ns Nameserver) ZoneTransfer {
+	result := ZoneTransfer{Zone: zone, Nameserver: ns.Host}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ns.IP.String(), nameserverPort))
+	if err != nil {
+		result.Err = err
+		return result
+	}
+	defer conn.Close()
+	// Closing the connection unblocks the transfer when ctx is done first.
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-finished:
+		}
+	}()
+
+	msg := new(dns.Msg)
+	msg.SetAxfr(dns.Fqdn(zone))
+	transfer := &dns.Transfer{
+		Conn:         &dns.Conn{Conn: conn},
+		DialTimeout:  timeout,
+		ReadTimeout:  2 * timeout,
+		WriteTimeout: timeout,
+	}
+	envelopes, err := transfer.In(msg, "")
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	// A stalled transfer ends with a read timeout error on the channel.
+	addresses := make(map[string][]net.IP)
+	targets := make(map[string]bool)
+	for envelope := range envelopes {
+		if envelope.Error != nil {
+			result.Err = envelope.Error
+			continue
+		}
+		for _, rr := range envelope.RR {
+			result.Records++
+			switch record := rr.(type) {
+			case *dns.A:
+				name := normalizeName(record.Hdr.Name)
+				addresses[name] = appendIP(addresses[name], record.A)
+			case *dns.AAAA:
+				name := normalizeName(record.Hdr.Name)
+				addresses[name] = appendIP(addresses[name], record.AAAA)
+			default:
+				for _, name := range transferTargets(rr) {
+					targets[normalizeName(name)] = true
+				}
+			}
+		}
+	}
+	if result.Records == 0 {
+		if result.Err == nil {
+			result.Err = errors.New("transfer refused or empty")
+		}
+		return result
+	}
+
+	var owners []string
+	for name := range addresses {
+		owners = append(owners, name)
+	}
+	sort.Strings(owners)
+	for _, name := range owners {
+		result.Hosts = append(result.Hosts, Subdomain{Host: name, IPs: addresses[name]})
+	}
+
+	var hosts []string
+	for name := range targets {
+		if name != "" && !strings.HasPrefix(name, "*") && addresses[name] == nil {
+			hosts = append(hosts, name)
+		}
+	}
+	sort.Strings(hosts)
+	if len(hosts) > maxTransferHosts {
+		hosts = hosts[:maxTransferHosts]
+	}
+	result.Hosts = append(result.Hosts, resolveHosts(ctx, hosts, transferConcurrency)...)
+	return result
+}
+
+// transferTargets returns the host names a CNAME, MX or SRV record points at.
+func transferTargets(rr dns.RR) []string {
+	switch record := rr.(type) {
+	case *dns.CNAME:
+		return []string{record.Target}
+	case *dns.MX:
+		return []string{record.Mx}
+	case *dns.SRV:
+		return []string{record.Target}
+	}
+	return nil
+}
+
+// normalizeName lowercases name and drops its trailing dot.
+func normalizeName(name string) string {
+	return strings.ToLower(strings.TrimSuffix(name, "."))
+}
+
+// appendIP adds ip to ips unless it is already there.
+func appendIP(ips []net.IP, ip net.IP) []net.IP {
+	for _, existing := range ips {
+		if existing.Equal(ip) {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
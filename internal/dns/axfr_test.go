@@ -0,0 +1,118 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+)
+
+var leakedZone = []string{
+	`leak.test. 300 IN SOA ns1.leak.test. admin.leak.test. 1 7200 3600 1209600 300`,
+	`leak.test. 300 IN NS ns1.leak.test.`,
+	`www.leak.test. 300 IN A 192.0.2.10`,
+	`www.leak.test. 300 IN AAAA 2001:db8::10`,
+	`API.leak.test. 300 IN A 192.0.2.11`,
+	`api.leak.test. 300 IN A 192.0.2.11`,
+	`*.leak.test. 300 IN A 192.0.2.12`,
+	`shop.leak.test. 300 IN CNAME www.leak.test.`,
+	`cdn.leak.test. 300 IN CNAME cdn.other.test.`,
+	`leak.test. 300 IN MX 10 mx.other.test.`,
+	`_sip._tcp.leak.test. 300 IN SRV 10 5 5060 sip.other.test.`,
+	`leak.test. 300 IN SOA ns1.leak.test. admin.leak.test. 1 7200 3600 1209600 300`,
+}
+
+// serveTransfer answers AXFR requests with zone on a local nameserver and
+// returns it.
+func serveTransfer(t *testing.T, zone []string) Nameserver {
+	t.Helper()
+	var records []dns.RR
+	for _, line := range zone {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		records = append(records, rr)
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on TCP: %v", err)
+	}
+	server := &dns.Server{Listener: listener, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		ch := make(chan *dns.Envelope)
+		transfer := new(dns.Transfer)
+		go func() {
+			ch <- &dns.Envelope{RR: records}
+			close(ch)
+		}()
+		transfer.Out(w, req, ch)
+		w.Close()
+	})}
+	go server.ActivateAndServe()
+	t.Cleanup(func() { server.Shutdown() })
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	previous := nameserverPort
+	nameserverPort = port
+	t.Cleanup(func() { nameserverPort = previous })
+	return Nameserver{Host: "ns1.leak.test", IP: net.ParseIP(host)}
+}
+
+func TestTransferZone(t *testing.T) {
+	// The recursive resolvers know only the names outside the zone.
+	serveZone(t, []string{
+		`mx.other.test. 60 IN A 198.51.100.20`,
+		`cdn.other.test. 60 IN A 198.51.100.30`,
+		`www.leak.test. 60 IN A 104.16.0.1`,
+	})
+	ns := serveTransfer(t, leakedZone)
+
+	result := TransferZone(context.Background(), "leak.test", ns)
+	if result.Err != nil {
+		t.Fatalf("transfer failed: %v", result.Err)
+	}
+	if result.Records != len(leakedZone) {
+		t.Errorf("Records = %d, want %d", result.Records, len(leakedZone))
+	}
+
+	// Addresses held in the zone come first, in name order, as
+	// transferred.
+	var hosts []string
+	for _, host := range result.Hosts {
+		var ips []string
+		for _, ip := range host.IPs {
+			ips = append(ips, ip.String())
+		}
+		hosts = append(hosts, host.Host+" "+strings.Join(ips, ","))
+	}
+	want := []string{
+		"*.leak.test 192.0.2.12",
+		"api.leak.test 192.0.2.11",
+		"www.leak.test 192.0.2.10,2001:db8::10",
+	}
+	if len(hosts) < len(want) || !reflect.DeepEqual(hosts[:len(want)], want) {
+		t.Fatalf("Hosts = %q, want %q first", hosts, want)
+	}
+
+	// Then the targets of CNAME, MX and SRV records, as resolved. Those
+	// without addresses are dropped.
+	resolved := hosts[len(want):]
+	sort.Strings(resolved)
+	if want := []string{"cdn.other.test 198.51.100.30", "mx.other.test 198.51.100.20"}; !reflect.DeepEqual(resolved, want) {
+		t.Errorf("resolved hosts = %q, want %q", resolved, want)
+	}
+}
+
+func TestTransferZoneRefused(t *testing.T) {
+	serveZone(t, nil)
+	ns := serveTransfer(t, nil)
+	result := TransferZone(context.Background(), "leak.test", ns)
+	if result.Records != 0 || result.Err == nil || len(result.Hosts) != 0 {
+		t.Errorf("TransferZone = %+v, want an error and no records", result)
+	}
+}
@@ -0,0 +1,127 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// negativeTTL is how long a name without addresses stays cached.
+const negativeTTL = time.Minute
+
+type cacheEntry struct {
+	ips     []net.IP
+	expires time.Time
+}
+
+// cache holds A/AAAA answers for as long as their TTL allows. Concurrent
+// lookups of the same name wait for the first one instead of querying again.
+var cache = struct {
+	sync.Mutex
+	entries  map[string]cacheEntry
+	inflight map[string]chan struct{}
+	hits     int
+	misses   int
+}{
+	entries:  make(map[string]cacheEntry),
+	inflight: make(map[string]chan struct{}),
+}
+
+// resetCache drops every cached answer, e.g. after the resolvers change.
+func resetCache() {
+	cache.Lock()
+	defer cache.Unlock()
+	cache.entries = make(map[string]cacheEntry)
+}
+
+// CacheStats returns the hit and miss counts of the resolution cache.
+func CacheStats() (hits, misses int) {
+	cache.Lock()
+	defer cache.Unlock()
+	return cache.hits, cache.misses
+}
+
+// resolve returns the A and AAAA addresses of host through the shared
+// client, answering from the cache while the records' TTL has not expired.
+// Failed lookups are not cached. When ctx is done resolve returns nil right
+// away; a query already sent still completes and fills the cache.
+func resolve(ctx context.Context, host string) []net.IP {
+	if ctx.Err() != nil {
+		return nil
+	}
+	key := strings.ToLower(strings.TrimSuffix(host, "."))
+
+	cache.Lock()
+	for {
+		if entry, ok := cache.entries[key]; ok && time.Now().Before(entry.expires) {
+			cache.hits++
+			cache.Unlock()
+			return entry.ips
+		}
+		wait, ok := cache.inflight[key]
+		if !ok {
+			break
+		}
+		cache.Unlock()
+		select {
+		case <-wait:
+		case <-ctx.Done():
+			return nil
+		}
+		cache.Lock()
+	}
+	cache.misses++
+	done := make(chan struct{})
+	cache.inflight[key] = done
+	cache.Unlock()
+
+	var ips []net.IP
+	go func() {
+		found, ttl, err := lookup(key)
+
+		cache.Lock()
+		if err == nil {
+			cache.entries[key] = cacheEntry{ips: found, expires: time.Now().Add(ttl)}
+		}
+		ips = found
+		delete(cache.inflight, key)
+		close(done)
+		cache.Unlock()
+	}()
+
+	select {
+	case <-done:
+		return ips
+	case <-ctx.Done():
+		return nil
+	}
+}
+
+// lookup queries the A and AAAA records of host and returns its addresses
+// along with how long they may be cached.
+func lookup(host string) ([]net.IP, time.Duration, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, 0, err
+	}
+	data, err := client.QueryMultiple(host, []uint16{dns.TypeA, dns.TypeAAAA})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var ips []net.IP
+	for _, address := range append(data.A, data.AAAA...) {
+		if ip := net.ParseIP(address); ip != nil {
+			ips = append(ips, ip)
+		}
+	}
+	ttl := time.Duration(data.TTL) * time.Second
+	if len(ips) == 0 || ttl == 0 {
+		ttl = negativeTTL
+	}
+	return ips, ttl, nil
+}
@@ -0,0 +1,143 @@
+package dns
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/projectdiscovery/retryabledns"
+)
+
+// DefaultResolvers are used when no resolver is configured.
+var DefaultResolvers = []string{"udp:1.1.1.1:53", "udp:8.8.8.8:53", "udp:8.8.4.4:53", "udp:1.0.0.1:53"}
+
+const (
+	// DefaultRetries is the number of attempts made for a query, each
+	// against the next resolver in the list.
+	DefaultRetries = 3
+	// DefaultTimeout bounds a single attempt.
+	DefaultTimeout = 5 * time.Second
+)
+
+var (
+	clientMu  sync.Mutex
+	client    *retryabledns.Client
+	resolvers = DefaultResolvers
+	retries   = DefaultRetries
+	timeout   = DefaultTimeout
+)
+
+// Configure sets the resolvers, retry count and per-attempt timeout of every
+// lookup. Resolvers are given in any form accepted by ParseResolver. An empty
+// list keeps the defaults. It must be called before the first lookup to take
+// effect.
+func Configure(list []string, maxRetries int, attemptTimeout time.Duration) error {
+	var parsed []string
+	for _, entry := range list {
+		resolver, err := ParseResolver(entry)
+		if err != nil {
+			return err
+		}
+		parsed = append(parsed, resolver)
+	}
+
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	if len(parsed) > 0 {
+		resolvers = parsed
+	}
+	if maxRetries > 0 {
+		retries = maxRetries
+	}
+	if attemptTimeout > 0 {
+		timeout = attemptTimeout
+	}
+	client = nil
+	resetCache()
+	return nil
+}
+
+// ParseResolver normalizes a resolver to the form used by the DNS client:
+//
+//	1.1.1.1, 1.1.1.1:53, udp:1.1.1.1:53   plain DNS over UDP
+//	tcp:1.1.1.1:53                        plain DNS over TCP
+//	dot:1.1.1.1:853, tls://1.1.1.1        DNS-over-TLS
+//	doh:https://..., https://...          DNS-over-HTTPS
+func ParseResolver(resolver string) (string, error) {
+	resolver = strings.TrimSpace(resolver)
+	switch {
+	case strings.HasPrefix(resolver, "https://"):
+		resolver = "doh:" + resolver
+	case strings.HasPrefix(resolver, "tls://"):
+		resolver = "dot:" + strings.TrimPrefix(resolver, "tls://")
+	}
+
+	protocol, address, ok := strings.Cut(resolver, ":")
+	switch {
+	case ok && protocol == "doh":
+		u, err := neturl.Parse(strings.TrimSuffix(strings.TrimSuffix(address, ":get"), ":post"))
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return "", fmt.Errorf("invalid DNS-over-HTTPS resolver %q", resolver)
+		}
+		return resolver, nil
+	case ok && (protocol == "udp" || protocol == "tcp" || protocol == "dot"):
+	default:
+		protocol, address = "udp", resolver
+	}
+
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		host, port = address, "53"
+		if protocol == "dot" {
+			port = "853"
+		}
+	}
+	if host == "" {
+		return "", fmt.Errorf("invalid resolver %q", resolver)
+	}
+	return protocol + ":" + net.JoinHostPort(host, port), nil
+}
+
+// ReadResolverFile returns the resolvers listed in path, one per line. Blank
+// lines and lines starting with '#' are skipped.
+func ReadResolverFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var list []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			list = append(list, line)
+		}
+	}
+	return list, scanner.Err()
+}
+
+// newClient returns the DNS client shared by every lookup.
+func newClient() (*retryabledns.Client, error) {
+	clientMu.Lock()
+	defer clientMu.Unlock()
+	if client != nil {
+		return client, nil
+	}
+	c, err := retryabledns.NewWithOptions(retryabledns.Options{
+		BaseResolvers: resolvers,
+		MaxRetries:    retries,
+		Timeout:       timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client = c
+	return client, nil
+}
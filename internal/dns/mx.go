@@ -0,0 +1,69 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// MXHost is a mail exchanger of a domain along with its addresses.
+type MXHost struct {
+	Host string
+	IPs  []net.IP
+}
+
+// GetMXRecords returns the mail exchangers of domain and the A and AAAA
+// addresses each of them resolves to.
+func GetMXRecords(ctx context.Context, domain string) ([]MXHost, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	data, err := client.MX(domain)
+	if err != nil {
+		return nil, err
+	}
+
+	var hosts []MXHost
+	for _, host := range data.MX {
+		if host == "" {
+			// A null MX ("0 .") means the domain accepts no mail.
+			continue
+		}
+		hosts = append(hosts, MXHost{Host: host, IPs: resolve(ctx, host)})
+	}
+	return hosts, nil
+}
+
+// SMTPBanner connects to the SMTP port of ip and returns the hostname the
+// server announces in its greeting ("220 mail.example.com ESMTP ...").
+func SMTPBanner(ctx context.Context, ip net.IP) (string, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(ip.String(), "25"))
+	if err != nil {
+		return "", err
+	}
+	defer conn.Close()
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	// The greeting may span several "220-" lines; the hostname is on the
+	// first one.
+	line, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	if !strings.HasPrefix(line, "220") {
+		return "", fmt.Errorf("unexpected SMTP greeting: %s", strings.TrimSpace(line))
+	}
+	fields := strings.Fields(strings.TrimLeft(line[3:], "- "))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("SMTP greeting carries no hostname")
+	}
+	return fields[0], nil
+}
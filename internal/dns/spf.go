@@ -0,0 +1,278 @@
+package dns
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"github.com/projectdiscovery/retryabledns"
+)
+
+// MaxSPFLookups is the RFC 7208 limit on the number of mechanisms and
+// modifiers that trigger a DNS lookup while evaluating a record.
+const MaxSPFLookups = 10
+
+// maxMXNames is the RFC 7208 limit on the MX hosts resolved per mechanism.
+const maxMXNames = 10
+
+// SPFAddress is an address derived from a domain's SPF policy.
+type SPFAddress struct {
+	IP net.IP
+	// Path lists the mechanisms that led to the address, outermost first,
+	// e.g. "include:_spf.example.com > ip4:192.0.2.10".
+	Path string
+}
+
+// SPFNetwork is a block authorized by an SPF policy that was too large to be
+// expanded into individual addresses.
+type SPFNetwork struct {
+	Network *net.IPNet
+	Path    string
+}
+
+// SPFResult is everything extracted from a domain's SPF policy.
+type SPFResult struct {
+	Addresses []SPFAddress
+	Networks  []SPFNetwork
+	// Lookups is the number of DNS-querying terms evaluated.
+	Lookups int
+}
+
+// ResolveSPF evaluates the SPF policy of domain, following include: and
+// redirect= and resolving a and mx mechanisms, and returns every address it
+// authorizes. Blocks of at most expandLimit addresses are expanded into
+// individual addresses; larger ones are returned as networks. Evaluation
+// stops at MaxSPFLookups, or when ctx is done, in which case the addresses
+// found so far are returned along with the error.
+func ResolveSPF(ctx context.Context, domain string, expandLimit int) (*SPFResult, error) {
+	client, err := newClient()
+	if err != nil {
+		return nil, err
+	}
+	r := &spfResolver{
+		ctx:         ctx,
+		client:      client,
+		expandLimit: expandLimit,
+		visited:     make(map[string]bool),
+		seen:        make(map[string]bool),
+	}
+	err = r.evaluate(domain, nil)
+	return &r.result, err
+}
+
+type spfResolver struct {
+	ctx         context.Context
+	client      *retryabledns.Client
+	expandLimit int
+	visited     map[string]bool
+	seen        map[string]bool
+	result      SPFResult
+}
+
+// evaluate walks the SPF record of domain. path holds the terms that led to
+// it.
+func (r *spfResolver) evaluate(domain string, path []string) error {
+	domain = strings.TrimSuffix(strings.ToLower(domain), ".")
+	if r.visited[domain] {
+		return nil
+	}
+	r.visited[domain] = true
+
+	record, err := r.record(domain)
+	if err != nil || record == "" {
+		return err
+	}
+
+	var redirect string
+	hasAll := false
+	for _, term := range strings.Fields(record)[1:] {
+		lower := strings.ToLower(term)
+		if strings.HasPrefix(lower, "redirect=") {
+			redirect = term[len("redirect="):]
+			continue
+		}
+		if strings.Contains(lower, "=") {
+			// exp= and unknown modifiers carry no addresses.
+			continue
+		}
+
+		mechanism := strings.TrimLeft(term, "+-~?")
+		name, arg, _ := strings.Cut(mechanism, ":")
+		name = strings.ToLower(name)
+		termPath := append(append([]string{}, path...), mechanism)
+
+		// "a" and "mx" may carry a prefix length without a domain
+		// ("a/24", "mx//64").
+		var cidr string
+		if i := strings.Index(name, "/"); i >= 0 {
+			name, cidr = name[:i], name[i:]
+		} else if i := strings.Index(arg, "/"); i >= 0 {
+			arg, cidr = arg[:i], arg[i:]
+		}
+
+		switch name {
+		case "all":
+			hasAll = true
+		case "ip4", "ip6":
+			r.addBlock(arg+cidr, termPath)
+		case "include":
+			if err := r.count(); err != nil {
+				return err
+			}
+			if isMacro(arg) {
+				continue
+			}
+			if err := r.evaluate(arg, termPath); err != nil {
+				return err
+			}
+		case "a":
+			if err := r.count(); err != nil {
+				return err
+			}
+			r.addHost(target(arg, domain), cidr, termPath)
+		case "mx":
+			if err := r.count(); err != nil {
+				return err
+			}
+			data, err := r.client.MX(target(arg, domain))
+			if err != nil {
+				continue
+			}
+			for i, host := range data.MX {
+				if i == maxMXNames {
+					break
+				}
+				r.addHost(host, cidr, append(append([]string{}, termPath...), "mx host "+host))
+			}
+		case "ptr", "exists":
+			// Both count towards the limit but authorize no fixed address.
+			if err := r.count(); err != nil {
+				return err
+			}
+		}
+	}
+
+	// A redirect only applies when the record has no "all" mechanism.
+	if redirect != "" && !hasAll && !isMacro(redirect) {
+		if err := r.count(); err != nil {
+			return err
+		}
+		return r.evaluate(redirect, append(append([]string{}, path...), "redirect="+redirect))
+	}
+	return nil
+}
+
+// record returns the SPF record of domain, or "" when it has none.
+func (r *spfResolver) record(domain string) (string, error) {
+	records, err := txtRecords(r.ctx, r.client, domain)
+	if err != nil {
+		return "", err
+	}
+	for _, txt := range records {
+		lower := strings.ToLower(txt)
+		if lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+			return txt, nil
+		}
+	}
+	return "", nil
+}
+
+func (r *spfResolver) count() error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	r.result.Lookups++
+	if r.result.Lookups > MaxSPFLookups {
+		return fmt.Errorf("SPF lookup limit of %d exceeded", MaxSPFLookups)
+	}
+	return nil
+}
+
+// addHost adds the addresses of host, widened to the given "/v4//v6" prefix
+// lengths when present.
+func (r *spfResolver) addHost(host, cidr string, path []string) {
+	if isMacro(host) {
+		return
+	}
+	v4, v6, _ := strings.Cut(strings.TrimPrefix(cidr, "/"), "//")
+	if strings.HasPrefix(cidr, "//") {
+		v4, v6 = "", strings.TrimPrefix(cidr, "//")
+	}
+	for _, ip := range resolve(r.ctx, host) {
+		address, prefix := ip.String(), v6
+		if ip.To4() != nil {
+			prefix = v4
+		}
+		if prefix != "" {
+			address += "/" + prefix
+		}
+		r.addBlock(address, path)
+	}
+}
+
+// addBlock adds an address or CIDR block, expanding blocks within the
+// expansion limit.
+func (r *spfResolver) addBlock(block string, path []string) {
+	pathStr := strings.Join(path, " > ")
+	if !strings.Contains(block, "/") {
+		r.addAddress(net.ParseIP(block), pathStr)
+		return
+	}
+
+	_, network, err := net.ParseCIDR(block)
+	if err != nil {
+		return
+	}
+	ones, bits := network.Mask.Size()
+	if ones == bits {
+		r.addAddress(network.IP, pathStr)
+		return
+	}
+	if bits-ones >= 31 || 1<<uint(bits-ones) > r.expandLimit {
+		r.result.Networks = append(r.result.Networks, SPFNetwork{Network: network, Path: pathStr})
+		return
+	}
+	for _, ip := range expand(network) {
+		r.addAddress(ip, pathStr)
+	}
+}
+
+func (r *spfResolver) addAddress(ip net.IP, path string) {
+	if ip == nil || r.seen[ip.String()] {
+		return
+	}
+	r.seen[ip.String()] = true
+	r.result.Addresses = append(r.result.Addresses, SPFAddress{IP: ip, Path: path})
+}
+
+// expand lists every address of a small network.
+func expand(network *net.IPNet) []net.IP {
+	var ips []net.IP
+	ip := append(net.IP{}, network.IP...)
+	for network.Contains(ip) {
+		ips = append(ips, append(net.IP{}, ip...))
+		for i := len(ip) - 1; i >= 0; i-- {
+			ip[i]++
+			if ip[i] != 0 {
+				break
+			}
+		}
+	}
+	return ips
+}
+
+// target returns the domain a mechanism applies to: its argument, or the
+// domain of the current record when it has none.
+func target(arg, domain string) string {
+	if arg == "" {
+		return domain
+	}
+	return arg
+}
+
+// isMacro reports whether a domain spec uses SPF macros, which depend on the
+// sender being evaluated and cannot be resolved here.
+func isMacro(spec string) bool {
+	return strings.Contains(spec, "%")
+}
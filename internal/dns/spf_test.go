@@ -0,0 +1,180 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// spfZone holds the records served to the SPF tests.
+var spfZone = []string{
+	`plain.test. 60 IN TXT "google-site-verification=abc"`,
+	`plain.test. 60 IN TXT "v=spf1 ip4:192.0.2.10 +ip4:192.0.2.16/30 ~ip6:2001:db8::1 ?ip4:10.0.0.0/8 exp=explain.plain.test -all"`,
+	`hosts.test. 60 IN TXT "v=spf1 a a:mail.hosts.test/31 mx -all"`,
+	`hosts.test. 60 IN A 192.0.2.50`,
+	`hosts.test. 60 IN MX 10 mx1.hosts.test.`,
+	`mail.hosts.test. 60 IN A 192.0.2.60`,
+	`mx1.hosts.test. 60 IN A 192.0.2.70`,
+	`include.test. 60 IN TXT "v=spf1 include:_spf.include.test include:%{i}._spf.include.test ~all"`,
+	`_spf.include.test. 60 IN TXT "v=spf1 ip4:198.51.100.1 include:include.test -all"`,
+	`redirect.test. 60 IN TXT "v=spf1 ip4:198.51.100.2 redirect=_spf.redirect.test"`,
+	`_spf.redirect.test. 60 IN TXT "v=spf1 ip4:198.51.100.3 -all"`,
+	`redirect-all.test. 60 IN TXT "v=spf1 ip4:198.51.100.4 redirect=_spf.redirect.test -all"`,
+	`split.test. 60 IN TXT "v=spf1 ip4:198.51.100.5 " "ip4:198.51.100.6 -all"`,
+	`limit.test. 60 IN TXT "v=spf1 ip4:198.51.100.7 a:a1.test a:a2.test a:a3.test a:a4.test a:a5.test a:a6.test mx:m1.test mx:m2.test ptr exists:%{i}.test include:late.test -all"`,
+	`late.test. 60 IN TXT "v=spf1 ip4:198.51.100.8 -all"`,
+}
+
+// serveZone answers queries for zone on a local resolver and points every
+// lookup at it.
+func serveZone(t *testing.T, zone []string) {
+	t.Helper()
+	records := make(map[string][]dns.RR)
+	for _, line := range zone {
+		rr, err := dns.NewRR(line)
+		if err != nil {
+			t.Fatalf("invalid record %q: %v", line, err)
+		}
+		key := strings.ToLower(rr.Header().Name) + " " + dns.TypeToString[rr.Header().Rrtype]
+		records[key] = append(records[key], rr)
+	}
+
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	server := &dns.Server{PacketConn: conn, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		for _, q := range req.Question {
+			resp.Answer = append(resp.Answer, records[strings.ToLower(q.Name)+" "+dns.TypeToString[q.Qtype]]...)
+		}
+		w.WriteMsg(resp)
+	})}
+	go server.ActivateAndServe()
+	t.Cleanup(func() {
+		server.Shutdown()
+		Configure(DefaultResolvers, DefaultRetries, DefaultTimeout)
+	})
+
+	if err := Configure([]string{conn.LocalAddr().String()}, 1, 2*time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestResolveSPF(t *testing.T) {
+	serveZone(t, spfZone)
+
+	tests := []struct {
+		domain    string
+		addresses []string
+		networks  []string
+		lookups   int
+		wantErr   bool
+	}{
+		{
+			domain: "plain.test",
+			addresses: []string{
+				"192.0.2.10 ip4:192.0.2.10",
+				"192.0.2.16 ip4:192.0.2.16/30",
+				"192.0.2.17 ip4:192.0.2.16/30",
+				"192.0.2.18 ip4:192.0.2.16/30",
+				"192.0.2.19 ip4:192.0.2.16/30",
+				"2001:db8::1 ip6:2001:db8::1",
+			},
+			networks: []string{"10.0.0.0/8 ip4:10.0.0.0/8"},
+		},
+		{
+			domain: "hosts.test",
+			addresses: []string{
+				"192.0.2.50 a",
+				"192.0.2.60 a:mail.hosts.test/31",
+				"192.0.2.61 a:mail.hosts.test/31",
+				"192.0.2.70 mx > mx host mx1.hosts.test",
+			},
+			lookups: 3,
+		},
+		{
+			// The include loop back to include.test is not followed again
+			// and the macro include is counted but not resolved.
+			domain:    "include.test",
+			addresses: []string{"198.51.100.1 include:_spf.include.test > ip4:198.51.100.1"},
+			lookups:   3,
+		},
+		{
+			domain: "redirect.test",
+			addresses: []string{
+				"198.51.100.2 ip4:198.51.100.2",
+				"198.51.100.3 redirect=_spf.redirect.test > ip4:198.51.100.3",
+			},
+			lookups: 1,
+		},
+		{
+			// redirect= is ignored when the record has an "all" mechanism.
+			domain:    "redirect-all.test",
+			addresses: []string{"198.51.100.4 ip4:198.51.100.4"},
+		},
+		{
+			domain:    "split.test",
+			addresses: []string{"198.51.100.5 ip4:198.51.100.5", "198.51.100.6 ip4:198.51.100.6"},
+		},
+		{
+			// The include is the eleventh DNS-querying term.
+			domain:    "limit.test",
+			addresses: []string{"198.51.100.7 ip4:198.51.100.7"},
+			lookups:   MaxSPFLookups + 1,
+			wantErr:   true,
+		},
+		{
+			domain: "missing.test",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			result, err := ResolveSPF(context.Background(), tt.domain, 16)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ResolveSPF(%s) error = %v, want error %t", tt.domain, err, tt.wantErr)
+			}
+			var addresses, networks []string
+			for _, a := range result.Addresses {
+				addresses = append(addresses, a.IP.String()+" "+a.Path)
+			}
+			for _, n := range result.Networks {
+				networks = append(networks, n.Network.String()+" "+n.Path)
+			}
+			if !reflect.DeepEqual(addresses, tt.addresses) {
+				t.Errorf("Addresses = %q, want %q", addresses, tt.addresses)
+			}
+			if !reflect.DeepEqual(networks, tt.networks) {
+				t.Errorf("Networks = %q, want %q", networks, tt.networks)
+			}
+			if result.Lookups != tt.lookups {
+				t.Errorf("Lookups = %d, want %d", result.Lookups, tt.lookups)
+			}
+		})
+	}
+}
+
+func TestResolveSPFExpandLimit(t *testing.T) {
+	serveZone(t, spfZone)
+
+	result, err := ResolveSPF(context.Background(), "plain.test", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var networks []string
+	for _, n := range result.Networks {
+		networks = append(networks, n.Network.String())
+	}
+	if want := []string{"192.0.2.16/30", "10.0.0.0/8"}; !reflect.DeepEqual(networks, want) {
+		t.Errorf("Networks = %v, want %v", networks, want)
+	}
+	if len(result.Addresses) != 2 {
+		t.Errorf("Addresses = %v, want the two single addresses", result.Addresses)
+	}
+}
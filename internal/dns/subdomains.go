@@ -0,0 +1,149 @@
+package dns
+
+import (
+	"bufio"
+	"context"
+	_ "embed"
+	"fmt"
+	"math/rand"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+// defaultWordlist holds subdomain labels that commonly point straight at an
+// origin: records teams forget to proxy, mail and panel hosts, old and
+// pre-production environments.
+//
+//go:embed wordlist.txt
+var defaultWordlist string
+
+// wildcardProbes is the number of random labels resolved to detect a
+// wildcard record.
+const wildcardProbes = 2
+
+// Subdomain is a resolved subdomain along with its addresses.
+type Subdomain struct {
+	Host string
+	IPs  []net.IP
+}
+
+// LoadWordlist returns the labels of the wordlist at path, or of the built-in
+// list when path is empty. Blank lines and lines starting with '#' are
+// skipped.
+func LoadWordlist(path string) ([]string, error) {
+	list := defaultWordlist
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		list = string(data)
+	}
+
+	var words []string
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		word := strings.Trim(strings.ToLower(strings.TrimSpace(scanner.Text())), ".")
+		if word == "" || strings.HasPrefix(word, "#") || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if len(words) == 0 {
+		return nil, fmt.Errorf("wordlist %s is empty", path)
+	}
+	return words, nil
+}
+
+// BruteForce resolves every word as a subdomain of domain using up to
+// concurrency parallel lookups and returns those that resolve. When domain
+// has a wildcard record, answers made up only of wildcard addresses are
+// dropped. Words not resolved by the time ctx is done are skipped.
+func BruteForce(ctx context.Context, domain string, words []string, concurrency int) ([]Subdomain, error) {
+	if _, err := newClient(); err != nil {
+		return nil, err
+	}
+
+	wildcard := wildcardIPs(ctx, domain)
+	hosts := make([]string, 0, len(words))
+	for _, word := range words {
+		hosts = append(hosts, word+"."+domain)
+	}
+
+	var subdomains []Subdomain
+	for _, subdomain := range resolveHosts(ctx, hosts, concurrency) {
+		if !onlyWildcard(subdomain.IPs, wildcard) {
+			subdomains = append(subdomains, subdomain)
+		}
+	}
+	return subdomains, nil
+}
+
+// resolveHosts resolves hosts using up to concurrency parallel lookups and
+// returns those that have at least one address. No lookup is started once
+// ctx is done.
+func resolveHosts(ctx context.Context, hosts []string, concurrency int) []Subdomain {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		resolved []Subdomain
+	)
+	jobs := make(chan string)
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range jobs {
+				ips := resolve(ctx, host)
+				if len(ips) == 0 {
+					continue
+				}
+				mu.Lock()
+				resolved = append(resolved, Subdomain{Host: host, IPs: ips})
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, host := range hosts {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- host
+	}
+	close(jobs)
+	wg.Wait()
+	return resolved
+}
+
+// wildcardIPs resolves random labels under domain and returns the addresses
+// they answer with, which are those of a wildcard record if there is one.
+func wildcardIPs(ctx context.Context, domain string) map[string]bool {
+	ips := make(map[string]bool)
+	for i := 0; i < wildcardProbes; i++ {
+		host := fmt.Sprintf("cfhero-%08x.%s", rand.Uint32(), domain)
+		for _, ip := range resolve(ctx, host) {
+			ips[ip.String()] = true
+		}
+	}
+	return ips
+}
+
+func onlyWildcard(ips []net.IP, wildcard map[string]bool) bool {
+	if len(wildcard) == 0 {
+		return false
+	}
+	for _, ip := range ips {
+		if !wildcard[ip.String()] {
+			return false
+		}
+	}
+	return true
+}
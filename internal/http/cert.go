@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"time"
+)
+
+// GetCertificate returns the leaf certificate presented by host:port.
+// serverName is sent as SNI; an empty serverName sends none, which makes most
+// servers fall back to their default certificate. The handshake is made
+// directly, without the configured proxy.
+func GetCertificate(ctx context.Context, host, port, serverName string) (*x509.Certificate, error) {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 5 * time.Second},
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         serverName,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("%s presented no certificate", net.JoinHostPort(host, port))
+	}
+	return certs[0], nil
+}
+
+// GetCertificates collects the distinct leaf certificates ip presents on each
+// open endpoint, once with serverName as SNI and once without SNI. Endpoints
+// not known to be open are checked first.
+func GetCertificates(ctx context.Context, ip, serverName string, endpoints []Endpoint) []*x509.Certificate {
+	var certs []*x509.Certificate
+	seen := make(map[string]bool)
+	for _, endpoint := range endpoints {
+		if !endpoint.Open && !CheckPort(ctx, ip, endpoint.Port) {
+			continue
+		}
+		for _, sni := range []string{serverName, ""} {
+			cert, err := GetCertificate(ctx, ip, endpoint.Port, sni)
+			if err != nil || seen[string(cert.Raw)] {
+				continue
+			}
+			seen[string(cert.Raw)] = true
+			certs = append(certs, cert)
+		}
+	}
+	return certs
+}
@@ -0,0 +1,178 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/bits"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxFaviconSize caps the size of a downloaded favicon.
+const maxFaviconSize = 1 << 20
+
+// FaviconHash returns the Shodan-style favicon hash: the signed 32-bit
+// MurmurHash3 of the icon encoded as MIME base64 (76-character lines, each
+// terminated by a newline), formatted as a decimal string.
+func FaviconHash(data []byte) string {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	var buf bytes.Buffer
+	for len(encoded) > 76 {
+		buf.WriteString(encoded[:76])
+		buf.WriteByte('\n')
+		encoded = encoded[76:]
+	}
+	buf.WriteString(encoded)
+	buf.WriteByte('\n')
+	return strconv.Itoa(int(int32(murmur3(buf.Bytes()))))
+}
+
+// murmur3 is the 32-bit x86 MurmurHash3 with a zero seed.
+func murmur3(data []byte) uint32 {
+	const c1, c2 = 0xcc9e2d51, 0x1b873593
+	var h uint32
+	n := len(data)
+	for i := 0; i+4 <= n; i += 4 {
+		k := binary.LittleEndian.Uint32(data[i:])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+		h = bits.RotateLeft32(h, 13)
+		h = h*5 + 0xe6546b64
+	}
+
+	var k uint32
+	tail := data[n&^3:]
+	switch len(tail) {
+	case 3:
+		k ^= uint32(tail[2]) << 16
+		fallthrough
+	case 2:
+		k ^= uint32(tail[1]) << 8
+		fallthrough
+	case 1:
+		k ^= uint32(tail[0])
+		k *= c1
+		k = bits.RotateLeft32(k, 15)
+		k *= c2
+		h ^= k
+	}
+
+	h ^= uint32(n)
+	h ^= h >> 16
+	h *= 0x85ebca6b
+	h ^= h >> 13
+	h *= 0xc2b2ae35
+	h ^= h >> 16
+	return h
+}
+
+// FaviconURLs returns the icon URLs declared by page's <link rel=icon> tags,
+// followed by /favicon.ico. They are resolved against the URL the page was
+// served from, or against base when there is no page.
+func FaviconURLs(base string, page *Page) []string {
+	if page != nil && page.URL != "" {
+		base = page.URL
+	}
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return nil
+	}
+
+	var urls []string
+	if page != nil {
+		if doc, err := html.Parse(bytes.NewReader(page.Body)); err == nil {
+			for _, href := range iconLinks(doc) {
+				if u, err := baseURL.Parse(href); err == nil {
+					urls = append(urls, u.String())
+				}
+			}
+		}
+	}
+	if u, err := baseURL.Parse("/favicon.ico"); err == nil {
+		urls = append(urls, u.String())
+	}
+	return urls
+}
+
+// iconLinks returns the href of every <link> whose rel names an icon.
+func iconLinks(doc *html.Node) []string {
+	var hrefs []string
+	var traverse func(*html.Node)
+	traverse = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.Data == "link" {
+			var rel, href string
+			for _, attr := range n.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "rel":
+					rel = strings.ToLower(attr.Val)
+				case "href":
+					href = strings.TrimSpace(attr.Val)
+				}
+			}
+			if href != "" && strings.Contains(rel, "icon") {
+				hrefs = append(hrefs, href)
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			traverse(c)
+		}
+	}
+	traverse(doc)
+	return hrefs
+}
+
+// GetFaviconHash downloads the first favicon in urls that answers with an
+// image and returns its hash. When ip is set, URLs pointing at host are sent
+// to ip instead and URLs on other hosts are skipped, so a candidate is never
+// credited with an icon served by a CDN or by Cloudflare.
+func GetFaviconHash(ctx context.Context, client *http.Client, urls []string, ip, host, userAgent string) (string, error) {
+	for _, rawURL := range urls {
+		u, err := neturl.Parse(rawURL)
+		if err != nil {
+			continue
+		}
+		hostHeader := u.Host
+		if ip != "" {
+			hostHeader = host
+			if strings.EqualFold(u.Hostname(), host) {
+				port := u.Port()
+				if port == "" {
+					port = DefaultPort(u.Scheme)
+				}
+				u.Host = net.JoinHostPort(ip, port)
+			} else if u.Hostname() != ip {
+				continue
+			}
+		}
+
+		req := RequestBuilderWithHost(ctx, u.String(), hostHeader, "GET", userAgent)
+		if req == nil {
+			continue
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			continue
+		}
+		data, err := io.ReadAll(io.LimitReader(resp.Body, maxFaviconSize))
+		resp.Body.Close()
+		if err != nil || resp.StatusCode != http.StatusOK || len(data) == 0 {
+			continue
+		}
+		if strings.HasPrefix(http.DetectContentType(data), "text/html") {
+			continue
+		}
+		return FaviconHash(data), nil
+	}
+	return "", fmt.Errorf("no favicon found")
+}
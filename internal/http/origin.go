@@ -0,0 +1,173 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Danny-Dasilva/CycleTLS/cycletls"
+	utls "github.com/Danny-Dasilva/utls"
+)
+
+// DefaultPort returns the well-known port for an HTTP scheme.
+func DefaultPort(scheme string) string {
+	if scheme == "https" {
+		return "443"
+	}
+	return "80"
+}
+
+// ja3DialTimeout bounds the connection and the handshake of DialJA3.
+const ja3DialTimeout = 10 * time.Second
+
+// DialJA3 opens a TLS connection to addr whose ClientHello follows the given
+// JA3 fingerprint and carries serverName as SNI. ALPN is pinned to http/1.1 so
+// the connection can be driven by net/http.
+func DialJA3(ctx context.Context, network, addr, serverName, ja3, userAgent string) (net.Conn, error) {
+	spec, err := cycletls.StringToSpec(ja3, userAgent)
+	if err != nil {
+		return nil, err
+	}
+	for _, ext := range spec.Extensions {
+		if alpn, ok := ext.(*utls.ALPNExtension); ok {
+			alpn.AlpnProtocols = []string{"http/1.1"}
+		}
+	}
+
+	dialer := &net.Dialer{Timeout: ja3DialTimeout}
+	rawConn, err := dialer.DialContext(ctx, network, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	conn := utls.UClient(rawConn, &utls.Config{ServerName: serverName, InsecureSkipVerify: true}, utls.HelloCustom)
+	if err := conn.ApplyPreset(spec); err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+
+	// The handshake takes no context: it is bounded by a deadline, and the
+	// connection is closed when ctx is done first.
+	deadline := time.Now().Add(ja3DialTimeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	rawConn.SetDeadline(deadline)
+	stop, stopped := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			rawConn.Close()
+		case <-stop:
+		}
+	}()
+	err = conn.Handshake()
+	close(stop)
+	<-stopped
+	if err == nil {
+		err = ctx.Err()
+	}
+	if err != nil {
+		rawConn.Close()
+		return nil, err
+	}
+	rawConn.SetDeadline(time.Time{})
+	return conn, nil
+}
+
+// NewTLSClient returns an HTTP client whose TLS connections carry serverName
+// as SNI (or the request host when serverName is empty). When ja3 is set,
+// HTTPS connections use that fingerprint. A proxy takes precedence over the
+// fingerprint because net/http performs its own handshake through a CONNECT
+// tunnel.
+func NewTLSClient(serverName, ja3, userAgent, proxy string) *http.Client {
+	client := NewHTTPClient(proxy, "")
+	transport := client.Transport.(*http.Transport)
+	transport.TLSClientConfig = &tls.Config{
+		InsecureSkipVerify: true,
+		ServerName:         serverName,
+	}
+	if ja3 != "" && proxy == "" {
+		transport.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+			sni := serverName
+			if sni == "" {
+				sni, _, _ = net.SplitHostPort(addr)
+			}
+			return DialJA3(ctx, network, addr, sni, ja3, userAgent)
+		}
+	}
+	return client
+}
+
+// NewOriginClient returns an HTTP client for requests sent directly to ip but
+// addressed to host: TLS connections carry host as SNI, and redirects back to
+// host stay pinned to ip so the check never falls through to Cloudflare.
+func NewOriginClient(ip, host, ja3, userAgent, proxy string) *http.Client {
+	client := NewTLSClient(host, ja3, userAgent, proxy)
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 10 {
+			return fmt.Errorf("stopped after 10 redirects")
+		}
+		if strings.EqualFold(req.URL.Hostname(), host) {
+			port := req.URL.Port()
+			if port == "" {
+				port = DefaultPort(req.URL.Scheme)
+			}
+			req.URL.Host = net.JoinHostPort(ip, port)
+			req.Host = host
+		}
+		return nil
+	}
+	return client
+}
+
+// GetPageWithHost fetches the page that ip serves for host at path, trying
+// each endpoint in order. Endpoints not known to be open are checked first,
+// and one without a scheme is probed for TLS. HTTPS is attempted with the JA3
+// fingerprint first and with the standard TLS stack as a fallback. The first
+// page carrying a title wins; otherwise the first page that answered at all
+// is returned. No endpoint is tried once ctx is done.
+func GetPageWithHost(ctx context.Context, ip, host, path string, endpoints []Endpoint, method, ja3, userAgent, proxy string) (*Page, error) {
+	var fallback *Page
+	for _, endpoint := range endpoints {
+		if ctx.Err() != nil {
+			break
+		}
+		if !endpoint.Open && !CheckPort(ctx, ip, endpoint.Port) {
+			continue
+		}
+		scheme := endpoint.Scheme
+		if scheme == "" {
+			scheme = DetectScheme(ctx, ip, endpoint.Port, host)
+		}
+
+		clients := []*http.Client{NewOriginClient(ip, host, "", userAgent, proxy)}
+		if scheme == "https" && ja3 != "" && proxy == "" {
+			clients = append([]*http.Client{NewOriginClient(ip, host, ja3, userAgent, proxy)}, clients...)
+		}
+
+		target := scheme + "://" + net.JoinHostPort(ip, endpoint.Port) + path
+		for _, client := range clients {
+			page, err := FetchPage(client, RequestBuilderWithHost(ctx, target, host, method, userAgent))
+			if err != nil {
+				continue
+			}
+			if page.Title != "" {
+				return page, nil
+			}
+			if fallback == nil {
+				fallback = page
+			}
+		}
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("no accessible ports found")
+}
@@ -0,0 +1,57 @@
+package http
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+)
+
+const testJA3 = "771,4865-4866-4867-49195-49199-49196-49200-52393-52392-49171-49172-156-157-47-53,0-23-65281-10-11-35-16-5-13-18-51-45-43-27-21,29-23-24,0"
+
+func TestDialJA3StalledHandshake(t *testing.T) {
+	// The server accepts connections but never answers the ClientHello.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	tests := []struct {
+		name string
+		ctx  func() (context.Context, context.CancelFunc)
+	}{
+		{"deadline", func() (context.Context, context.CancelFunc) {
+			return context.WithTimeout(context.Background(), 200*time.Millisecond)
+		}},
+		{"cancel", func() (context.Context, context.CancelFunc) {
+			ctx, cancel := context.WithCancel(context.Background())
+			time.AfterFunc(200*time.Millisecond, cancel)
+			return ctx, cancel
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := tt.ctx()
+			defer cancel()
+			start := time.Now()
+			conn, err := DialJA3(ctx, "tcp", listener.Addr().String(), "example.com", testJA3, "")
+			if err == nil {
+				conn.Close()
+				t.Fatal("handshake with a silent server succeeded")
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("DialJA3 returned after %s, want it bounded by the context", elapsed)
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package http
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	neturl "net/url"
+
+	"golang.org/x/net/html"
+)
+
+// maxBodySize caps how much of a response body is kept for comparison.
+const maxBodySize = 2 << 20
+
+// Page is the part of an HTTP response used to compare a candidate origin
+// against the Cloudflare-fronted site.
+type Page struct {
+	// Scheme and Port are those of the original request, before any
+	// redirect.
+	Scheme     string
+	Port       string
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+	Title      string
+	// Redirect is the Location of the first redirect that led to this page,
+	// as sent by the server.
+	Redirect string
+	// Favicon is the Shodan-style hash of the site's favicon. It is only
+	// filled in when a caller downloads the icon with GetFaviconHash.
+	Favicon string
+}
+
+// FetchPage sends req with client and reads the response into a Page.
+func FetchPage(client *http.Client, req *http.Request) (*Page, error) {
+	if req == nil {
+		return nil, fmt.Errorf("invalid request")
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+	if err != nil {
+		return nil, err
+	}
+
+	port := req.URL.Port()
+	if port == "" {
+		port = DefaultPort(req.URL.Scheme)
+	}
+	page := &Page{
+		Scheme:     req.URL.Scheme,
+		Port:       port,
+		URL:        resp.Request.URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       body,
+	}
+	for r := resp.Request; r.Response != nil; r = r.Response.Request {
+		page.Redirect = r.Response.Header.Get("Location")
+	}
+	if doc, err := html.Parse(bytes.NewReader(body)); err == nil {
+		page.Title = GetHTMLTitle(doc)
+	}
+	return page, nil
+}
+
+// GetPage fetches urlStr through its normal DNS resolution, i.e. through
+// Cloudflare. The JA3 fingerprint is tried first, then the standard TLS stack.
+func GetPage(ctx context.Context, urlStr, method, ja3, userAgent, proxy string) (*Page, error) {
+	parsedURL, err := neturl.Parse(urlStr)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := []*http.Client{NewTLSClient("", "", userAgent, proxy)}
+	if parsedURL.Scheme == "https" && ja3 != "" && proxy == "" {
+		clients = append([]*http.Client{NewTLSClient("", ja3, userAgent, proxy)}, clients...)
+	}
+
+	var fallback *Page
+	for _, client := range clients {
+		page, err := FetchPage(client, RequestBuilderWithHost(ctx, urlStr, parsedURL.Host, method, userAgent))
+		if err != nil {
+			continue
+		}
+		if page.Title != "" {
+			return page, nil
+		}
+		if fallback == nil {
+			fallback = page
+		}
+	}
+
+	if fallback != nil {
+		return fallback, nil
+	}
+	return nil, fmt.Errorf("%s did not respond", urlStr)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultPorts are probed on every candidate when no -ports list is given.
+var DefaultPorts = []string{"80", "443"}
+
+// wellKnownSchemes maps the ports Cloudflare proxies to the scheme they are
+// conventionally served with. Other ports have their scheme detected.
+var wellKnownSchemes = map[string]string{
+	"80": "http", "8080": "http", "8880": "http", "2052": "http", "2082": "http", "2086": "http", "2095": "http",
+	"443": "https", "8443": "https", "2053": "https", "2083": "https", "2087": "https", "2096": "https",
+}
+
+// Endpoint is a port to probe on a candidate. An empty Scheme means the scheme
+// is detected when the port is probed.
+type Endpoint struct {
+	Scheme string
+	Port   string
+	// Open means the port is known to be open, so it is not checked before
+	// being requested.
+	Open bool
+}
+
+// String returns the endpoint in the form accepted by ParseEndpoints.
+func (e Endpoint) String() string {
+	if e.Scheme == "" {
+		return e.Port
+	}
+	return e.Scheme + ":" + e.Port
+}
+
+// ParseEndpoints parses a port list. Each entry is a port number, optionally
+// prefixed with the scheme to use ("https:8443"). Entries without a scheme
+// use the conventional scheme of well-known ports and are detected otherwise.
+// Duplicates are dropped.
+func ParseEndpoints(specs []string) ([]Endpoint, error) {
+	var endpoints []Endpoint
+	seen := make(map[Endpoint]bool)
+	for _, spec := range specs {
+		spec = strings.TrimSpace(strings.ToLower(spec))
+		if spec == "" {
+			continue
+		}
+		var endpoint Endpoint
+		if scheme, port, ok := strings.Cut(spec, ":"); ok {
+			if scheme != "http" && scheme != "https" {
+				return nil, fmt.Errorf("invalid scheme %q in port %q", scheme, spec)
+			}
+			endpoint = Endpoint{Scheme: scheme, Port: port}
+		} else {
+			endpoint = Endpoint{Scheme: wellKnownSchemes[spec], Port: spec}
+		}
+		if n, err := strconv.Atoi(endpoint.Port); err != nil || n < 1 || n > 65535 {
+			return nil, fmt.Errorf("invalid port %q", spec)
+		}
+		if !seen[endpoint] {
+			seen[endpoint] = true
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	return endpoints, nil
+}
+
+// AddPorts appends the given ports to endpoints unless they are already
+// listed. Used for ports a source reported open on a candidate.
+func AddPorts(endpoints []Endpoint, ports []int) []Endpoint {
+	result := append([]Endpoint{}, endpoints...)
+	for _, n := range ports {
+		if n < 1 || n > 65535 {
+			continue
+		}
+		port := strconv.Itoa(n)
+		listed := false
+		for _, endpoint := range result {
+			if endpoint.Port == port {
+				listed = true
+				break
+			}
+		}
+		if !listed {
+			result = append(result, Endpoint{Scheme: wellKnownSchemes[port], Port: port})
+		}
+	}
+	return result
+}
+
+// OpenPorts returns endpoints for ports known to be open on a candidate.
+func OpenPorts(ports []int) []Endpoint {
+	var endpoints []Endpoint
+	for _, port := range AddPorts(nil, ports) {
+		port.Open = true
+		endpoints = append(endpoints, port)
+	}
+	return endpoints
+}
+
+// DetectScheme reports whether host:port speaks TLS by attempting a
+// handshake, returning "https" if it does and "http" otherwise.
+func DetectScheme(ctx context.Context, host, port, serverName string) string {
+	dialer := &tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 3 * time.Second},
+		Config: &tls.Config{
+			InsecureSkipVerify: true,
+			ServerName:         serverName,
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		return "http"
+	}
+	conn.Close()
+	return "https"
+}
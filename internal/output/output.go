@@ -0,0 +1,73 @@
+package output
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+)
+
+// Writer emits one JSON object per line. It is safe for concurrent use by the
+// scan workers.
+type Writer struct {
+	mu      sync.Mutex
+	writers []io.Writer
+	file    *os.File
+}
+
+// New returns a Writer that writes to stdout when stdout is set and to the
+// file at path when path is not empty. The file is appended to when appendFile
+// is set, e.g. when a scan is resumed, and truncated otherwise. It returns nil
+// when neither is requested; a nil Writer discards everything.
+func New(path string, stdout, appendFile bool) (*Writer, error) {
+	if path == "" && !stdout {
+		return nil, nil
+	}
+
+	w := &Writer{}
+	if stdout {
+		w.writers = append(w.writers, os.Stdout)
+	}
+	if path != "" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if appendFile {
+			flags = os.O_WRONLY | os.O_CREATE | os.O_APPEND
+		}
+		file, err := os.OpenFile(path, flags, 0o644)
+		if err != nil {
+			return nil, err
+		}
+		w.file = file
+		w.writers = append(w.writers, file)
+	}
+	return w, nil
+}
+
+// Write encodes v as a single JSON line.
+func (w *Writer) Write(v interface{}) error {
+	if w == nil {
+		return nil
+	}
+	line, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for _, writer := range w.writers {
+		if _, err := writer.Write(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes the output file, if any.
+func (w *Writer) Close() error {
+	if w == nil || w.file == nil {
+		return nil
+	}
+	return w.file.Close()
+}
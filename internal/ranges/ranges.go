@@ -0,0 +1,209 @@
+package ranges
+
+import (
+	"bufio"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	httpClient "github.com/musana/cf-hero/internal/http"
+)
+
+// DefaultURL is where Cloudflare publishes its ranges. The lists are read
+// from <url>/ips-v4 and <url>/ips-v6.
+const DefaultURL = "https://www.cloudflare.com/"
+
+// MaxAge is how long a cached list is used before a refresh is attempted.
+const MaxAge = 7 * 24 * time.Hour
+
+var (
+	//go:embed ips-v4
+	snapshotV4 string
+	//go:embed ips-v6
+	snapshotV6 string
+)
+
+var (
+	mu        sync.Mutex
+	sourceURL = DefaultURL
+	proxyURL  string
+	// defaultSet is published once loaded, so classification reads it
+	// without taking mu.
+	defaultSet atomic.Pointer[Set]
+	// loadErr describes why defaultSet fell back to an older list.
+	loadErr error
+)
+
+// cacheFile is the on-disk format of the cached lists.
+type cacheFile struct {
+	Updated time.Time `json:"updated"`
+	IPv4    []string  `json:"ipv4"`
+	IPv6    []string  `json:"ipv6"`
+}
+
+// Configure sets where the lists are downloaded from and through which
+// proxy. It must be called before the first lookup to take effect.
+func Configure(url, proxy string) {
+	mu.Lock()
+	defer mu.Unlock()
+	if url != "" {
+		sourceURL = url
+	}
+	proxyURL = proxy
+}
+
+// Default returns the Cloudflare ranges used for classification, loading them
+// on first use as described in Load. Once loaded, the set is read without
+// locking.
+func Default() *Set {
+	if set := defaultSet.Load(); set != nil {
+		return set
+	}
+	set, _ := Load()
+	return set
+}
+
+// Load returns the Cloudflare ranges used for classification. On first use
+// they are read from the cache; a missing or stale cache is refreshed from
+// the source URL, and when that fails the stale cache or, lacking one, the
+// embedded snapshot is used. The set is always usable; the error reports a
+// fallback or a cache that could not be written.
+func Load() (*Set, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if set := defaultSet.Load(); set != nil {
+		return set, loadErr
+	}
+
+	var set *Set
+	cached, err := readCache()
+	if err == nil && time.Since(cached.Updated) < MaxAge {
+		set = NewSet(cached.IPv4, cached.IPv6)
+	} else if set, err = update(sourceURL, proxyURL); err == nil {
+		loadErr = writeCache(set)
+	} else if cached != nil {
+		loadErr = fmt.Errorf("could not refresh Cloudflare IP ranges (%v), using the list cached on %s", err, cached.Updated.Format("2006-01-02"))
+		set = NewSet(cached.IPv4, cached.IPv6)
+	} else {
+		loadErr = fmt.Errorf("could not refresh Cloudflare IP ranges (%v), using the built-in list", err)
+		set = Snapshot()
+	}
+	defaultSet.Store(set)
+	return set, loadErr
+}
+
+// Snapshot returns the ranges embedded at build time.
+func Snapshot() *Set {
+	return NewSet(parseList(snapshotV4), parseList(snapshotV6))
+}
+
+// Update downloads the lists from the configured source, writes them to the
+// cache and makes them the default set.
+func Update() (*Set, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	set, err := update(sourceURL, proxyURL)
+	if err != nil {
+		return nil, err
+	}
+	loadErr = nil
+	defaultSet.Store(set)
+	return set, writeCache(set)
+}
+
+// CachePath returns the location of the cached lists.
+func CachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "cf-hero", "cloudflare-ranges.json")
+}
+
+func update(url, proxy string) (*Set, error) {
+	v4, err := download(url, "ips-v4", proxy)
+	if err != nil {
+		return nil, err
+	}
+	v6, err := download(url, "ips-v6", proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	set := NewSet(v4, v6)
+	if len(set.IPv4) == 0 {
+		return nil, fmt.Errorf("no valid IPv4 ranges in %s", url)
+	}
+	return set, nil
+}
+
+func download(base, name, proxy string) ([]string, error) {
+	client := httpClient.NewHTTPClient(proxy, "")
+	client.Timeout = 15 * time.Second
+
+	resp, err := client.Get(strings.TrimSuffix(base, "/") + "/" + name)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("%s returned status code %d", name, resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	return parseList(string(body)), nil
+}
+
+// parseList returns the valid CIDRs of a newline-separated list.
+func parseList(list string) []string {
+	var cidrs []string
+	scanner := bufio.NewScanner(strings.NewReader(list))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if _, _, err := net.ParseCIDR(line); err == nil {
+			cidrs = append(cidrs, line)
+		}
+	}
+	return cidrs
+}
+
+func readCache() (*cacheFile, error) {
+	data, err := os.ReadFile(CachePath())
+	if err != nil {
+		return nil, err
+	}
+	var cached cacheFile
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil, err
+	}
+	if len(cached.IPv4) == 0 {
+		return nil, fmt.Errorf("cache %s holds no IPv4 ranges", CachePath())
+	}
+	return &cached, nil
+}
+
+func writeCache(set *Set) error {
+	path := CachePath()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not cache Cloudflare IP ranges: %v", err)
+	}
+	data, err := json.MarshalIndent(cacheFile{Updated: time.Now().UTC(), IPv4: set.IPv4, IPv6: set.IPv6}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("could not cache Cloudflare IP ranges: %v", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("could not cache Cloudflare IP ranges: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package ranges
+
+import "net"
+
+// trie is a binary prefix trie over IP address bits. A lookup costs at most
+// one step per address bit regardless of how many prefixes are stored.
+type trie struct {
+	root node
+}
+
+type node struct {
+	children [2]*node
+	terminal bool
+}
+
+// insert adds the prefix described by ip and its first bits bits.
+func (t *trie) insert(ip []byte, bits int) {
+	n := &t.root
+	for i := 0; i < bits; i++ {
+		if n.terminal {
+			// A shorter prefix already covers this one.
+			return
+		}
+		b := bit(ip, i)
+		if n.children[b] == nil {
+			n.children[b] = &node{}
+		}
+		n = n.children[b]
+	}
+	n.terminal = true
+	n.children = [2]*node{}
+}
+
+// contains reports whether ip falls within any stored prefix.
+func (t *trie) contains(ip []byte) bool {
+	n := &t.root
+	for i := 0; i < len(ip)*8; i++ {
+		if n.terminal {
+			return true
+		}
+		n = n.children[bit(ip, i)]
+		if n == nil {
+			return false
+		}
+	}
+	return n.terminal
+}
+
+func bit(ip []byte, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
+
+// Set classifies addresses against a list of CIDR prefixes.
+type Set struct {
+	v4 trie
+	v6 trie
+	// IPv4 and IPv6 are the prefixes the set was built from.
+	IPv4 []string
+	IPv6 []string
+}
+
+// NewSet builds a Set from CIDR strings. Invalid entries are skipped.
+func NewSet(v4, v6 []string) *Set {
+	s := &Set{}
+	for _, cidr := range append(append([]string{}, v4...), v6...) {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		bits, _ := network.Mask.Size()
+		if ip4 := network.IP.To4(); ip4 != nil {
+			s.v4.insert(ip4, bits)
+			s.IPv4 = append(s.IPv4, network.String())
+		} else {
+			s.v6.insert(network.IP.To16(), bits)
+			s.IPv6 = append(s.IPv6, network.String())
+		}
+	}
+	return s
+}
+
+// Contains reports whether ip belongs to one of the set's prefixes.
+func (s *Set) Contains(ip net.IP) bool {
+	if ip4 := ip.To4(); ip4 != nil {
+		return s.v4.contains(ip4)
+	}
+	if ip16 := ip.To16(); ip16 != nil {
+		return s.v6.contains(ip16)
+	}
+	return false
+}
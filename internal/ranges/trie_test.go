@@ -0,0 +1,106 @@
+package ranges
+
+import (
+	"net"
+	"reflect"
+	"testing"
+)
+
+func TestSetContains(t *testing.T) {
+	set := NewSet(
+		[]string{"173.245.48.0/20", "104.16.0.0/13", "198.51.100.7/32", "192.0.2.0/24", "192.0.0.0/16", "not-a-cidr"},
+		[]string{"2606:4700::/32", "2001:db8:abcd::/48", "2001:db8:abcd:12::1/128"},
+	)
+
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		// IPv4
+		{"173.245.48.0", true},
+		{"173.245.63.255", true},
+		{"173.245.64.0", false},
+		{"173.245.47.255", false},
+		{"104.16.0.1", true},
+		{"104.23.255.255", true},
+		{"104.24.0.0", false},
+		{"198.51.100.7", true},
+		{"198.51.100.8", false},
+		// A /24 inserted before the /16 that covers it.
+		{"192.0.2.10", true},
+		{"192.0.200.1", true},
+		{"192.1.0.0", false},
+		{"8.8.8.8", false},
+		// IPv4-mapped IPv6 is classified as IPv4.
+		{"::ffff:104.16.0.1", true},
+		{"::ffff:8.8.8.8", false},
+		// IPv6
+		{"2606:4700::1", true},
+		{"2606:4700:ffff:ffff:ffff:ffff:ffff:ffff", true},
+		{"2606:4701::", false},
+		{"2001:db8:abcd:ffff::1", true},
+		{"2001:db8:abce::1", false},
+		{"2001:db8:abcd:12::1", true},
+		{"2001:db8::1", false},
+		{"::1", false},
+	}
+	for _, tt := range tests {
+		if got := set.Contains(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("Contains(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+
+	if set.Contains(nil) {
+		t.Error("Contains(nil) = true, want false")
+	}
+	wantV4 := []string{"173.245.48.0/20", "104.16.0.0/13", "198.51.100.7/32", "192.0.2.0/24", "192.0.0.0/16"}
+	if !reflect.DeepEqual(set.IPv4, wantV4) {
+		t.Errorf("IPv4 = %v, want %v", set.IPv4, wantV4)
+	}
+	if len(set.IPv6) != 3 {
+		t.Errorf("IPv6 = %v, want 3 prefixes", set.IPv6)
+	}
+}
+
+func TestSetContainsNormalizesPrefixes(t *testing.T) {
+	// Host bits set in the CIDR are masked off.
+	set := NewSet([]string{"10.1.2.3/8"}, []string{"fd00::1/8"})
+	tests := []struct {
+		ip   string
+		want bool
+	}{
+		{"10.255.0.1", true},
+		{"11.0.0.0", false},
+		{"fdff::1", true},
+		{"fe00::1", false},
+	}
+	for _, tt := range tests {
+		if got := set.Contains(net.ParseIP(tt.ip)); got != tt.want {
+			t.Errorf("Contains(%s) = %t, want %t", tt.ip, got, tt.want)
+		}
+	}
+	if set.IPv4[0] != "10.0.0.0/8" {
+		t.Errorf("IPv4[0] = %s, want 10.0.0.0/8", set.IPv4[0])
+	}
+}
+
+func TestSetEverything(t *testing.T) {
+	set := NewSet([]string{"0.0.0.0/0"}, []string{"::/0"})
+	for _, ip := range []string{"0.0.0.0", "255.255.255.255", "::", "ffff::1"} {
+		if !set.Contains(net.ParseIP(ip)) {
+			t.Errorf("Contains(%s) = false, want true", ip)
+		}
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	set := Snapshot()
+	if len(set.IPv4) == 0 || len(set.IPv6) == 0 {
+		t.Fatalf("snapshot holds %d IPv4 and %d IPv6 prefixes", len(set.IPv4), len(set.IPv6))
+	}
+	for _, ip := range []string{"104.16.0.1", "172.67.1.1", "2606:4700::6810:1"} {
+		if !set.Contains(net.ParseIP(ip)) {
+			t.Errorf("snapshot does not contain %s", ip)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package scanner
+
+import (
+	"context"
+	"net"
+
+	"github.com/musana/cf-hero/internal/sources"
+	"github.com/musana/cf-hero/internal/target"
+	"github.com/musana/cf-hero/internal/verify"
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// faviconSearch pivots on the target's favicon hash: every enabled source
+// that supports it is asked for hosts serving the same icon, which finds
+// origins that never appeared in DNS. It reports whether every search is
+// complete.
+func (s *Scanner) faviconSearch(ctx context.Context, t target.Target, url string, cfIP net.IP, baseline *verify.Baseline) bool {
+	domain, hash := t.Host, baseline.Favicon
+	if s.Options.Verbose {
+		s.logf(models.LevelNotice, "\n[*] Favicon hash of %s: %s", domain, hash)
+	}
+
+	complete := true
+	for _, source := range s.sources {
+		if favicon, ok := source.(sources.FaviconSource); ok {
+			complete = s.track(ctx, t, url, source.Name()+" favicon", func(run *sourceRun) {
+				s.runSource(ctx, run, func(ctx context.Context) <-chan models.Candidate {
+					return favicon.DiscoverFavicon(ctx, domain, hash)
+				}, domain, cfIP, baseline)
+			}) && complete
+		}
+	}
+	return complete
+}
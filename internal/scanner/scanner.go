@@ -1,72 +1,212 @@
 package scanner
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"context"
+	"crypto/sha256"
 	"fmt"
-	"io"
 	"net"
-	"net/http"
-	neturl "net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/fatih/color"
 	"github.com/gammazero/workerpool"
-	"github.com/musana/cf-hero/internal/config"
+	"github.com/musana/cf-hero/internal/candidates"
+	"github.com/musana/cf-hero/internal/checkpoint"
 	"github.com/musana/cf-hero/internal/dns"
 	httpClient "github.com/musana/cf-hero/internal/http"
+	"github.com/musana/cf-hero/internal/sources"
+	"github.com/musana/cf-hero/internal/target"
+	"github.com/musana/cf-hero/internal/verify"
 	"github.com/musana/cf-hero/pkg/models"
-	"github.com/schollz/progressbar/v3"
 )
 
+// subdomainConcurrency is the number of parallel lookups per brute-forced
+// target.
+const subdomainConcurrency = 20
+
+// candidateConcurrency is the number of user supplied candidates verified in
+// parallel per target.
+const candidateConcurrency = 20
+
+// neighborSource prefixes the source of candidates found next to a confirmed
+// origin.
+const neighborSource = "Neighbor of "
+
+// neighborRanges names the technique verifying the neighbors of confirmed
+// origins.
+const neighborRanges = "Neighbor ranges"
+
+// Scanner runs every enabled technique against its targets. It prints
+// nothing: log lines and findings are handed to the emit function given to
+// New.
 type Scanner struct {
 	Options *models.Options
-	URLs    []string
+	Targets []target.Target
+	// Domains holds the hostnames whose non-Cloudflare addresses are
+	// verified against every target (the -dl list).
 	Domains []string
-	Bar     *progressbar.ProgressBar
-	mu      sync.Mutex
-	Stats   struct {
-		Total           int
-		Behind          int
-		NotBehind       int
-		TotalIPsScanned int
-		RealIPsFound    int
+	// Wordlist holds the subdomain labels brute forced when -brute is set.
+	Wordlist []string
+	// Candidates holds the user supplied and imported hosts verified
+	// against every target.
+	Candidates []models.Candidate
+	// Checkpoint, when set, records the progress of the scan and lets it
+	// skip the work a previous run completed.
+	Checkpoint *checkpoint.Checkpoint
+	mu         sync.Mutex
+	Stats      models.Stats
+
+	emit func(models.Event)
+	// endpoints are the ports probed on every candidate.
+	endpoints    []httpClient.Endpoint
+	sourceConfig *sources.Config
+	// sources are the enabled passive discovery sources.
+	sources []sources.Source
+	// zoneTransfers records every AXFR attempt for the summary.
+	zoneTransfers []models.ZoneTransfer
+	// neighbors holds, per target URL, the neighbors of confirmed origins
+	// waiting to be verified.
+	neighbors map[string]*neighborQueue
+	// techniques identifies the enabled techniques, their parameters and the
+	// verification settings in the checkpoint, and settings the latter alone.
+	techniques string
+	settings   string
+	// params identifies the inputs of each technique other than the
+	// verification settings, by technique name.
+	params map[string]string
+}
+
+// sourceRun tracks the candidates one technique or passive source produces
+// for a target.
+type sourceRun struct {
+	name   string
+	target string
+	// url is the target URL candidates are verified against.
+	url string
+	// params identifies the inputs of the technique, see Scanner.params.
+	params        string
+	found         int
+	nonCloudflare int
+	// failed reports that a passive source ran into an error, so its run is
+	// not recorded as complete.
+	failed bool
+}
+
+type neighborQueue struct {
+	pending []models.Candidate
+	// seen holds every address confirmed or queued for the target.
+	seen map[string]bool
+	// checked holds every address verified against the target, by any
+	// technique.
+	checked map[string]bool
+}
+
+// New returns a scanner for options. Sources read their keys through
+// apiKeys, and every event of the scan is handed to emit.
+func New(options *models.Options, apiKeys func(id string) []string, emit func(models.Event)) *Scanner {
+	s := &Scanner{
+		Options:   options,
+		emit:      emit,
+		neighbors: make(map[string]*neighborQueue),
+	}
+
+	s.sourceConfig = &sources.Config{
+		Proxy:        options.Proxy,
+		UserAgent:    options.UserAgent,
+		FaviconPages: options.FaviconPages,
+		APIKeys:      apiKeys,
+		Logf:         s.logf,
+	}
+	for _, source := range sources.All(s.sourceConfig) {
+		for _, id := range options.Sources {
+			if source.ID() == id {
+				s.sources = append(s.sources, source)
+			}
+		}
+	}
+
+	// The list is validated before the scanner is created.
+	s.endpoints, _ = httpClient.ParseEndpoints(options.Ports)
+	if len(s.endpoints) == 0 {
+		s.endpoints, _ = httpClient.ParseEndpoints(httpClient.DefaultPorts)
+	}
+	return s
+}
+
+// AddTargets parses input lines into targets. Invalid lines are reported and
+// skipped.
+func (s *Scanner) AddTargets(lines []string) {
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		t, err := target.Parse(line)
+		if err != nil {
+			s.logf(models.LevelWarning, "[!] Invalid target %q: %v. Skipping...", line, err)
+			continue
+		}
+		s.Targets = append(s.Targets, t)
 	}
 }
 
-func New(options *models.Options, urls []string, domains []string) *Scanner {
-	// Filter valid URLs
-	var validURLs []string
-	for _, url := range urls {
-		if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-			validURLs = append(validURLs, url)
+// AddDomains adds the hostnames of input lines to Domains.
+func (s *Scanner) AddDomains(lines []string) {
+	for _, line := range lines {
+		if t, err := target.Parse(line); err == nil {
+			s.Domains = append(s.Domains, t.Host)
 		}
 	}
+}
 
-	return &Scanner{
-		Options: options,
-		URLs:    validURLs,
-		Domains: domains,
+// Run pre-scans the targets, then scans those behind Cloudflare with
+// Options.Worker workers. Once ctx is done no target is started, those in
+// progress stop early, and Run returns when every worker is idle.
+func (s *Scanner) Run(ctx context.Context) {
+	s.PreScan(ctx)
+
+	wp := workerpool.New(s.Options.Worker)
+	for _, t := range s.Targets {
+		t := t
+		switch s.Checkpoint.TargetDone(t.Input) {
+		case "":
+		case s.techniques:
+			s.logf(models.LevelInfo, "[*] %s was completed in a previous run. Skipping...", t.Input)
+			continue
+		default:
+			s.logf(models.LevelInfo, "[*] %s was completed in a previous run with other techniques, inputs or verification settings. Scanning it again...", t.Input)
+		}
+		wp.Submit(func() {
+			if ctx.Err() == nil {
+				s.Start(ctx, t)
+			}
+		})
 	}
+	wp.StopWait()
 }
 
-func (s *Scanner) PreScan() {
-	color.White("\n[*] Pre-scanning domains to identify Cloudflare protected ones...")
+// PreScan counts the targets behind Cloudflare and announces the techniques
+// and sources the scan uses. Targets are not looked up once ctx is done.
+func (s *Scanner) PreScan(ctx context.Context) {
+	s.logf(models.LevelInfo, "\n[*] Pre-scanning domains to identify Cloudflare protected ones...")
 	processed := 0
 
 	wp := workerpool.New(s.Options.Worker)
 	var wg sync.WaitGroup
 
-	for _, url := range s.URLs {
-		url := url // capture variable
+	for _, t := range s.Targets {
+		t := t // capture variable
 		wg.Add(1)
 		wp.Submit(func() {
 			defer wg.Done()
-			domain := strings.Split(url, "//")[1]
-			cfIPs, _ := dns.GetARecords(domain)
+			if ctx.Err() != nil {
+				return
+			}
+			cfIPs, _ := dns.GetARecords(ctx, t.Host)
+			if ctx.Err() != nil {
+				return
+			}
 
 			s.mu.Lock()
 			processed++
@@ -83,790 +223,988 @@ func (s *Scanner) PreScan() {
 	wg.Wait()
 	wp.StopWait()
 
-	color.White("[+] Found %d/%d domains behind Cloudflare", s.Stats.Behind, s.Stats.Total)
+	s.logf(models.LevelInfo, "[+] Found %d/%d domains behind Cloudflare", s.Stats.Behind, s.Stats.Total)
 
 	// Show provided HTML title if exists
 	if s.Options.Title != "" {
-		color.Cyan("[*] Using provided HTML title: %s", s.Options.Title)
+		s.logf(models.LevelNotice, "[*] Using provided HTML title: %s", s.Options.Title)
 	}
 
 	// Build technique string
 	var techniques []string
-	techniques = append(techniques, "DNS (a, txt records)") // Always included
+	techniques = append(techniques, "DNS (a, aaaa, spf records, axfr)") // Always included
+	techniques = append(techniques, "MX records")
+	if s.Options.Authoritative {
+		techniques = append(techniques, "Authoritative nameservers")
+	}
+	if s.Options.Brute {
+		techniques = append(techniques, fmt.Sprintf("Subdomain brute force (%d words)", len(s.Wordlist)))
+	}
 
-	if s.Options.Censys {
-		techniques = append(techniques, "Censys")
+	for _, source := range s.sources {
+		techniques = append(techniques, source.Name())
 	}
-	if s.Options.SecurityTrails {
-		techniques = append(techniques, "SecurityTrails")
+	if s.Options.Favicon {
+		techniques = append(techniques, "Favicon hash")
 	}
-	if s.Options.Shodan {
-		techniques = append(techniques, "Shodan")
+	if len(s.Candidates) > 0 {
+		techniques = append(techniques, fmt.Sprintf("Candidate list (%d hosts)", len(s.Candidates)))
 	}
-	if s.Options.Zoomeye {
-		techniques = append(techniques, "ZoomEye")
+	if s.Options.Neighbors > 0 {
+		techniques = append(techniques, fmt.Sprintf("Neighbor ranges (/%d)", s.Options.Neighbors))
 	}
 
-	color.Cyan("[*] Techniques: %s", strings.Join(techniques, ", "))
+	s.logf(models.LevelNotice, "[*] Techniques: %s", strings.Join(techniques, ", "))
 
 	// Check API keys status at the beginning of the scan
-	color.Cyan("\n[*] Checking API keys...")
+	s.logf(models.LevelNotice, "\n[*] Checking API keys...")
 
-	// Censys API key check
-	if keys := config.ReadAPIKeys("censys"); len(keys) > 0 && keys[0] != "" {
-		color.Green("[*] Censys API found in the config file")
-	} else {
-		color.Yellow("[!] Censys API could not find in the config file")
-		s.Options.Censys = false
+	var ready []sources.Source
+	for _, source := range sources.All(s.sourceConfig) {
+		if sources.HasKeys(source, s.sourceConfig) {
+			s.logf(models.LevelSuccess, "[*] %s API key found", source.Name())
+		} else {
+			s.logf(models.LevelWarning, "[!] %s API key could not be found", source.Name())
+		}
 	}
-
-	// SecurityTrails API key check
-	if keys := config.ReadAPIKeys("securitytrails"); len(keys) > 0 && keys[0] != "" {
-		color.Green("[*] SecurityTrails API found in the config file")
-	} else {
-		color.Yellow("[!] SecurityTrails API could not find in the config file")
-		s.Options.SecurityTrails = false
+	for _, source := range s.sources {
+		if sources.HasKeys(source, s.sourceConfig) {
+			ready = append(ready, source)
+		}
 	}
+	s.sources = ready
+	s.settings = s.verification()
+	s.params = s.techniqueParams()
+	s.techniques = s.fingerprint()
+	s.logf(models.LevelNotice, "\n[*] Scan has been started for targets...")
+}
 
-	// Shodan API key check
-	if keys := config.ReadAPIKeys("shodan"); len(keys) > 0 && keys[0] != "" {
-		color.Green("[*] Shodan API found in the config file")
-	} else {
-		color.Yellow("[!] Shodan API could not find in the config file")
-		s.Options.Shodan = false
+// Start scans a single target. With Options.TargetTimeout set, the target
+// gets that long before its remaining techniques are skipped. Every step
+// stops early when ctx is done.
+//
+// The target is recorded as complete in the checkpoint only once it resolved
+// and every technique ran to completion. A target that did not resolve, had
+// no usable baseline, timed out or had a source fail is scanned again by a
+// resumed run, which skips the techniques it completed.
+func (s *Scanner) Start(ctx context.Context, t target.Target) {
+	parent := ctx
+	complete := false
+	defer func() {
+		if complete {
+			s.record(s.Checkpoint.MarkTargetDone(t.Input, s.techniques))
+		}
+	}()
+	if s.Options.TargetTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.Options.TargetTimeout)
+		defer cancel()
+		defer func() {
+			if ctx.Err() != nil && parent.Err() == nil {
+				s.mu.Lock()
+				s.Stats.TimedOut++
+				s.mu.Unlock()
+				s.logf(models.LevelWarning, "[!] %s timed out after %s. Its remaining techniques were skipped.", t.Host, s.Options.TargetTimeout)
+			}
+		}()
 	}
 
-	// Zoomeye API key check
-	if keys := config.ReadAPIKeys("zoomeye"); len(keys) > 0 && keys[0] != "" {
-		color.Green("[*] ZoomEye API found in the config file")
-	} else {
-		color.Yellow("[!] ZoomEye API could not find in the config file")
-		s.Options.Zoomeye = false
-	}
-	color.Cyan("\n[*] Scan has been started for targets...")
-
-	if s.Stats.Behind > 0 {
-		s.Bar = progressbar.NewOptions(s.Stats.Behind,
-			progressbar.OptionEnableColorCodes(true),
-			progressbar.OptionShowCount(),
-			progressbar.OptionSetWidth(40),
-			progressbar.OptionSetDescription("[cyan][*][reset] Scanning Cloudflare protected domains..."),
-			progressbar.OptionSetTheme(progressbar.Theme{
-				Saucer:        "[green]=[reset]",
-				SaucerHead:    "[green]>[reset]",
-				SaucerPadding: " ",
-				BarStart:      "[",
-				BarEnd:        "]",
-			}))
-	}
-}
-
-func (s *Scanner) Start(url string) {
-	if s.Options.CF || s.Options.NCF {
-		s.printDomains(url)
+	domain := t.Host
+	cfIPs, nonCFIPs := dns.GetARecords(ctx, domain)
+	if ctx.Err() != nil {
 		return
 	}
+	s.publish(models.Event{Type: models.EventTargetResolved, Target: t.Input, IPs: append(append([]net.IP{}, cfIPs...), nonCFIPs...)})
+	s.publish(models.Event{Type: models.EventCloudflareStatus, Target: t.Input, Cloudflare: len(cfIPs) > 0})
 
-	// Check if URL starts with http or https
-	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
-		color.Red("[!] %s does not start with http or https. Skipping...", url)
-		return
-	}
+	if len(cfIPs) > 0 {
+		baseline, url := s.getBaseline(ctx, t)
 
-	domain := strings.Split(url, "//")[1]
-	cfIPs, nonCFIPs := dns.GetARecords(domain)
+		s.logf(models.LevelInfo, "[*] Target Information: [ %s (%s) (Cloudflare) - Title: %s ]", domain, cfIPs[0], baseline.Title)
 
-	if len(cfIPs) > 0 {
-		var actualHTMLTitle string
-		if s.Options.Title != "" {
-			actualHTMLTitle = s.Options.Title
-		} else {
-			actualHTMLTitle, _ = s.getHTMLTitle(url)
+		if !baseline.Usable() {
+			if baseline.Challenge {
+				s.logf(models.LevelWarning, "[!] %s answered with a Cloudflare challenge. Use -title to provide the real title. Marking it as unverifiable...", domain)
+			} else {
+				s.logf(models.LevelWarning, "[!] %s has no usable baseline (no title, body, redirect or certificate). Marking it as unverifiable...", domain)
+			}
+			s.mu.Lock()
+			s.Stats.Unverifiable++
+			s.mu.Unlock()
+			return
+		}
+		if !baseline.PageUsable() {
+			if baseline.Challenge {
+				s.logf(models.LevelWarning, "[!] %s answered with a Cloudflare challenge. Use -title to provide the real title. Verifying candidates by TLS certificate only...", domain)
+			} else {
+				s.logf(models.LevelWarning, "[!] %s has no usable page baseline. Verifying candidates by TLS certificate only...", domain)
+			}
 		}
 
-		color.White("[*] Target Information: [ %s (%s) (Cloudflare) - Title: %s ]", domain, cfIPs[0], actualHTMLTitle)
+		// track runs a technique, noting when it does not complete.
+		complete = true
+		track := func(name string, check func(run *sourceRun)) {
+			if !s.track(ctx, t, url, name, check) {
+				complete = false
+			}
+		}
+
+		cfIP := cfIPs[0]
+		if s.Options.Neighbors > 0 && !s.Checkpoint.SourceDone(t.Input, neighborRanges, s.key(neighborRanges)) {
+			// Origins confirmed in a previous run still seed the neighbor
+			// ranges they were not verified in.
+			for _, origin := range s.Checkpoint.Origins(t.Input) {
+				if ip := net.ParseIP(origin); ip != nil {
+					s.queueNeighbors(url, ip)
+				}
+			}
+		}
 
 		if len(nonCFIPs) > 0 {
-			s.checkARecords(url, nonCFIPs, cfIPs[0], actualHTMLTitle)
-			s.mu.Lock()
-			s.Stats.TotalIPsScanned += len(nonCFIPs)
-			s.mu.Unlock()
+			track("A/AAAA records", func(run *sourceRun) {
+				s.checkARecords(ctx, run, nonCFIPs, cfIP, baseline)
+			})
 		}
 
-		s.getTXTRecords(domain, url, cfIPs[0], actualHTMLTitle)
+		track("SPF", func(run *sourceRun) {
+			s.checkSPF(ctx, run, domain, cfIP, baseline)
+		})
+		track("MX records", func(run *sourceRun) {
+			s.checkMXRecords(ctx, run, domain, cfIP, baseline)
+		})
+		track("Zone transfer", func(run *sourceRun) {
+			s.checkZoneTransfer(ctx, run, domain, cfIP, baseline)
+		})
+
+		if s.Options.Authoritative {
+			track("Authoritative nameservers", func(run *sourceRun) {
+				s.checkAuthoritative(ctx, run, domain, cfIP, baseline)
+			})
+		}
 
-		if s.Options.Censys {
-			s.censysSearch(domain, url, cfIPs[0], actualHTMLTitle)
+		if s.Options.Brute {
+			track("Subdomain brute force", func(run *sourceRun) {
+				s.checkSubdomains(ctx, run, domain, cfIP, baseline)
+			})
 		}
 
-		if s.Options.SecurityTrails {
-			s.securityTrailsSearch(domain, url, cfIPs[0], actualHTMLTitle)
+		for _, source := range s.sources {
+			source := source
+			track(source.Name(), func(run *sourceRun) {
+				s.runSource(ctx, run, func(ctx context.Context) <-chan models.Candidate {
+					return source.Discover(ctx, domain)
+				}, domain, cfIP, baseline)
+			})
 		}
 
-		if s.Options.Shodan {
-			s.shodanSearch(domain, url, cfIPs[0], actualHTMLTitle)
+		if s.Options.Favicon && baseline.Favicon != "" && !s.faviconSearch(ctx, t, url, cfIP, baseline) {
+			complete = false
 		}
 
-		if s.Options.Zoomeye {
-			s.zoomeyeSearch(domain, url, cfIPs[0], actualHTMLTitle)
+		if len(s.Candidates) > 0 {
+			track("Candidate list", func(run *sourceRun) {
+				s.checkCandidates(ctx, run, domain, cfIP, baseline)
+			})
 		}
 
-		if s.Options.DomainList != "" && s.Options.TargetDomain != "" {
-			s.checkDomainList(url, cfIPs[0], actualHTMLTitle)
+		if len(s.Domains) > 0 {
+			track("Domain list", func(run *sourceRun) {
+				s.checkDomainList(ctx, run, cfIP, baseline)
+			})
 		}
 
-		// Print final results only for the last domain
-		if url == s.URLs[len(s.URLs)-1] {
-			color.White("\n[*] Scan finished. %d real IP(s) found out of %d IP(s) scanned.", s.Stats.RealIPsFound, s.Stats.TotalIPsScanned)
+		if s.Options.Neighbors > 0 {
+			track(neighborRanges, func(run *sourceRun) {
+				s.checkNeighbors(ctx, run, domain, cfIP, baseline)
+			})
+			// Every technique is done; drop the addresses tracked for url.
+			s.mu.Lock()
+			delete(s.neighbors, url)
+			s.mu.Unlock()
 		}
+	} else if len(nonCFIPs) > 0 {
+		s.logf(models.LevelError, "[!] %s is not behind Cloudflare. Skipping...", domain)
+		complete = true
 	} else {
-		color.Red("[!] %s is not behind Cloudflare. Skipping...", domain)
+		// A lookup that failed looks the same as a name without records,
+		// so the target is not recorded as complete.
+		s.logf(models.LevelError, "[!] %s could not be resolved. Skipping...", domain)
 	}
 }
 
-func (s *Scanner) printDomains(url string) {
-	domain := strings.Split(url, "//")[1]
-	cfIPs, nonCFIPs := dns.GetARecords(domain)
+// Summary returns the statistics of the scan once every target has been
+// processed.
+func (s *Scanner) Summary() models.Summary {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := s.Stats
+	stats.DNSCacheHits, stats.DNSCacheMisses = dns.CacheStats()
+	return models.Summary{
+		Type:          "summary",
+		Stats:         stats,
+		ZoneTransfers: append([]models.ZoneTransfer{}, s.zoneTransfers...),
+		Timestamp:     time.Now().UTC(),
+	}
+}
 
-	if s.Options.CF {
-		if len(cfIPs) > 0 {
-			fmt.Println(url)
-		}
+// fingerprint identifies the enabled techniques and their parameters, the
+// ready sources and the verification settings, so that a target completed in
+// a previous run is scanned again when they change.
+func (s *Scanner) fingerprint() string {
+	parts := []string{fmt.Sprintf("authoritative=%t brute=%t favicon=%t",
+		s.Options.Authoritative, s.Options.Brute, s.Options.Favicon)}
+	names := make([]string, 0, len(s.params))
+	for name := range s.params {
+		names = append(names, name)
 	}
-	if s.Options.NCF {
-		if len(nonCFIPs) > 0 {
-			fmt.Println(url)
-		}
+	sort.Strings(names)
+	for _, name := range names {
+		parts = append(parts, fmt.Sprintf("%q=%s", name, s.params[name]))
 	}
+	for _, source := range s.sources {
+		parts = append(parts, source.ID())
+	}
+	parts = append(parts, s.settings)
+	return strings.Join(parts, " ")
 }
 
-func (s *Scanner) checkDomainList(url string, cfIP net.IP, actualHTMLTitle string) {
-	for _, d := range s.Domains {
-		parseIt := strings.Split(d, "//")
-		domain := parseIt[1]
-
-		targetDomain := strings.Split(s.Options.TargetDomain, "//")[1]
-
-		_, nonCFIPs := dns.GetARecords(domain)
-
-		if len(nonCFIPs) > 0 {
-			for _, ip := range nonCFIPs {
-				htmlTitle := s.checkHTMLTitle("http://"+ip.String(), targetDomain)
-				if htmlTitle == actualHTMLTitle {
-					s.printResult(url, cfIP, ip, "DNS A Record", actualHTMLTitle)
-				}
-			}
+// techniqueParams identifies the inputs of each technique that takes any
+// besides the verification settings. Lists are identified by a hash of their
+// contents, so that a list of the same length with other entries is not
+// mistaken for the one a previous run completed.
+func (s *Scanner) techniqueParams() map[string]string {
+	var candidateList []string
+	for _, candidate := range s.Candidates {
+		candidateList = append(candidateList, fmt.Sprintf("%s %v %t %s", candidate.IP, candidate.Ports, candidate.PortsOpen, candidate.Source))
+	}
+	params := map[string]string{
+		"SPF":                   fmt.Sprintf("spf-expand=%d", s.Options.SPFExpand),
+		"MX records":            fmt.Sprintf("smtp-banner=%t", s.Options.SMTPBanner),
+		"Subdomain brute force": "wordlist=" + digest(s.Wordlist),
+		"Candidate list":        "candidates=" + digest(candidateList),
+		"Domain list":           "domains=" + digest(s.Domains),
+		neighborRanges:          fmt.Sprintf("neighbors=%d/%d", s.Options.Neighbors, s.Options.MaxNeighbors),
+	}
+	for _, source := range s.sources {
+		if _, ok := source.(sources.FaviconSource); ok {
+			params[source.Name()+" favicon"] = fmt.Sprintf("favicon-pages=%d", s.Options.FaviconPages)
 		}
 	}
+	return params
 }
 
-func (s *Scanner) checkHTMLTitle(urlStr string, hostHeader string) string {
-	// URL'den host kısmını çıkar
-	parsedURL, err := neturl.Parse(urlStr)
-	if err != nil {
-		return ""
+// key identifies, in the checkpoint, the verification settings and the
+// inputs the technique or source name runs with.
+func (s *Scanner) key(name string) string {
+	if params := s.params[name]; params != "" {
+		return s.settings + " " + params
 	}
+	return s.settings
+}
 
-	title, err := httpClient.GetHTMLTitleWithPortCheck(parsedURL.Host, s.Options.JA3, s.Options.UserAgent, s.Options.Proxy)
-	if err != nil {
-		return ""
-	}
-	return title
+// digest identifies the contents of a list.
+func digest(list []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(list, "\n")))
+	return fmt.Sprintf("%x", sum[:8])
 }
 
-func (s *Scanner) getHTMLTitle(urlStr string) (string, error) {
-	// URL'den host kısmını çıkar
-	parsedURL, err := neturl.Parse(urlStr)
+// verification identifies every setting that affects how candidates are
+// verified. A technique completed with other settings runs again, reusing
+// the candidates its source returned. The settings are hashed, since the
+// proxy URL may hold credentials.
+func (s *Scanner) verification() string {
+	settings := strings.Join([]string{
+		strings.Join(s.Options.Ports, ","),
+		strings.Join(s.Options.CertPorts, ","),
+		strconv.Itoa(s.Options.Threshold),
+		s.Options.Title,
+		s.Options.HTTPMethod,
+		s.Options.UserAgent,
+		s.Options.JA3,
+		s.Options.Proxy,
+	}, "\x00")
+	sum := sha256.Sum256([]byte(settings))
+	return fmt.Sprintf("settings=%x", sum[:8])
+}
+
+// record reports a checkpoint that could not be written. The scan goes on.
+func (s *Scanner) record(err error) {
 	if err != nil {
-		return "", err
+		s.logf(models.LevelWarning, "[!] Could not write checkpoint: %v", err)
 	}
+}
 
-	return httpClient.GetHTMLTitleWithPortCheck(parsedURL.Host, s.Options.JA3, s.Options.UserAgent, s.Options.Proxy)
+// logf publishes a log event.
+func (s *Scanner) logf(level models.Level, format string, args ...interface{}) {
+	s.publish(models.Event{Type: models.EventLog, Level: level, Message: fmt.Sprintf(format, args...)})
 }
 
-func (s *Scanner) checkARecords(url string, ips []net.IP, cfIP net.IP, actualHTMLTitle string) {
-	for _, ip := range ips {
+// track runs a technique or passive source against a target, framed by
+// source_started and source_finished events. Nothing is run once ctx is
+// done, nor when a previous run completed it with the same settings and
+// inputs. The neighbor ranges run again whenever neighbors are queued, since
+// origins found in this run may have added some. track reports whether the
+// technique is complete, either now or in a previous run.
+func (s *Scanner) track(ctx context.Context, t target.Target, url, name string, check func(run *sourceRun)) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	key := s.key(name)
+	if s.Checkpoint.SourceDone(t.Input, name, key) && !(name == neighborRanges && s.hasPendingNeighbors(url)) {
 		if s.Options.Verbose {
-			color.Cyan("[*] Non-Cloudflare IP(%s) found in %s's A record. Checking it...", ip.String(), url)
+			s.logf(models.LevelInfo, "[*] %s of %s was completed in a previous run. Skipping...", name, t.Host)
 		}
-		s.mu.Lock()
-		s.Stats.TotalIPsScanned++
-		s.mu.Unlock()
-		s.compareTitle(url, ip, cfIP, "A - Record", actualHTMLTitle)
+		return true
+	}
+	run := &sourceRun{name: name, target: t.Input, url: url, params: s.params[name]}
+	s.publish(models.Event{Type: models.EventSourceStarted, Target: run.target, URL: url, Source: name})
+	check(run)
+	complete := ctx.Err() == nil && !run.failed
+	if complete {
+		s.record(s.Checkpoint.MarkSourceDone(t.Input, name, key))
 	}
+	s.publish(models.Event{
+		Type:          models.EventSourceFinished,
+		Target:        run.target,
+		URL:           url,
+		Source:        name,
+		Found:         run.found,
+		NonCloudflare: run.nonCloudflare,
+	})
+	return complete
 }
 
-func (s *Scanner) getTXTRecords(domain, url string, cfIP net.IP, actualHTMLTitle string) {
-	txtRecords, err := dns.GetTXTRecords(domain)
-	if err != nil {
+// discovered publishes a candidate produced by run and reports whether it
+// lies outside Cloudflare's ranges and should be verified. Candidates in the
+// ranges are rejected right away.
+func (s *Scanner) discovered(run *sourceRun, candidate models.Candidate) bool {
+	isCloudflare, _ := dns.IsInCloudflareIPRange(candidate.IP)
+	run.found++
+	if !isCloudflare {
+		run.nonCloudflare++
+	}
+	s.publish(models.Event{
+		Type:       models.EventCandidateDiscovered,
+		Target:     run.target,
+		URL:        run.url,
+		Source:     run.name,
+		Candidate:  &candidate,
+		Cloudflare: isCloudflare,
+	})
+	if isCloudflare {
+		s.publish(models.Event{
+			Type:      models.EventCandidateRejected,
+			Target:    run.target,
+			URL:       run.url,
+			Source:    run.name,
+			Candidate: &candidate,
+			Reason:    "in Cloudflare's IP ranges",
+		})
+	}
+	return !isCloudflare
+}
+
+func (s *Scanner) publish(event models.Event) {
+	if s.emit == nil {
 		return
 	}
+	event.Time = time.Now().UTC()
+	s.emit(event)
+}
 
-	var extractedIPs []string
-	for _, txt := range txtRecords {
-		extractedIP := dns.ExtractIPAddresses(txt)
-		if len(extractedIP) > 0 {
-			for _, ipAddress := range extractedIP {
-				if !strings.Contains(strings.Join(extractedIPs, ","), ipAddress) {
-					extractedIPs = append(extractedIPs, ipAddress)
-				}
-			}
+func (s *Scanner) checkDomainList(ctx context.Context, run *sourceRun, cfIP net.IP, baseline *verify.Baseline) {
+	for _, domain := range s.Domains {
+		_, nonCFIPs := dns.GetARecords(ctx, domain)
 
-			for _, ipx := range extractedIPs {
-				netIP := net.ParseIP(ipx)
-				if netIP.To4() != nil {
-					if s.Options.Verbose {
-						color.Magenta("[*] Non-Cloudflare IP(%s) found in %s's TXT record. Checking it...", netIP.String(), domain)
-					}
-					s.mu.Lock()
-					s.Stats.TotalIPsScanned++
-					s.mu.Unlock()
-					s.compareTitle(url, netIP, cfIP, "TXT - DNS Record", actualHTMLTitle)
-				}
+		for _, ip := range nonCFIPs {
+			candidate := models.Candidate{IP: ip, Source: "DNS A Record"}
+			if s.discovered(run, candidate) {
+				s.compareTitle(ctx, run, candidate, cfIP, baseline)
 			}
 		}
 	}
 }
 
-func (s *Scanner) censysSearch(domain, url string, cfIP net.IP, actualHTMLTitle string) {
-	// Censys Platform API. The config "censys" list holds the Personal Access
-	// Token (PAT) as the first entry and, optionally, the Organization ID as
-	// the second entry (required for paid tiers):
-	//   censys:
-	//     - "censys_pat_xxxxxxxx"
-	//     - "your-organization-id"   # optional
-	keys := config.ReadAPIKeys("censys")
-	if len(keys) == 0 || keys[0] == "" {
-		return
+// verifyCandidate fetches the page the candidate serves at the path of url
+// when asked for its host, both as the Host header and as the TLS SNI, and
+// scores it against the baseline. The port of url, when explicit, is probed
+// first, then the configured ports along with any extra ports a source saw
+// open on the candidate. A candidate whose open ports are known, e.g. from a
+// port scan, is probed on those ports only.
+// The TLS certificates the candidate presents are scored as an additional
+// signal. The returned page is nil when it did not answer over HTTP, and ok
+// reports whether the score reaches the configured threshold.
+func (s *Scanner) verifyCandidate(ctx context.Context, url string, candidate models.Candidate, baseline *verify.Baseline) (*httpClient.Page, verify.Result, bool) {
+	t, _ := target.Parse(url)
+	ip, hostHeader := candidate.IP, t.Host
+
+	endpoints := httpClient.AddPorts(s.endpoints, candidate.Ports)
+	if t.Port != "" {
+		endpoints = append([]httpClient.Endpoint{{Scheme: t.Scheme, Port: t.Port}}, endpoints...)
 	}
-	pat := keys[0]
-	var orgID string
-	if len(keys) > 1 {
-		orgID = keys[1]
+	var certEndpoints []httpClient.Endpoint
+	for _, port := range append([]string{"443"}, s.Options.CertPorts...) {
+		certEndpoints = append(certEndpoints, httpClient.Endpoint{Port: port})
 	}
-
-	censysURL := "https://api.platform.censys.io/v3/global/search/query"
-	if orgID != "" {
-		censysURL += "?organization_id=" + neturl.QueryEscape(orgID)
+	if candidate.PortsOpen {
+		// Only the open ports are probed, without checking them first,
+		// and those known to serve plain HTTP carry no certificate.
+		endpoints = httpClient.OpenPorts(candidate.Ports)
+		certEndpoints = nil
+		for _, endpoint := range endpoints {
+			if endpoint.Scheme != "http" {
+				certEndpoints = append(certEndpoints, endpoint)
+			}
+		}
+	}
+	page, err := httpClient.GetPageWithHost(ctx, ip.String(), hostHeader, t.Path, endpoints, s.Options.HTTPMethod, s.Options.JA3, s.Options.UserAgent, s.Options.Proxy)
+	if err == nil && baseline.Favicon != "" {
+		client := httpClient.NewOriginClient(ip.String(), hostHeader, "", s.Options.UserAgent, s.Options.Proxy)
+		page.Favicon, _ = httpClient.GetFaviconHash(ctx, client, httpClient.FaviconURLs("", page), ip.String(), hostHeader, s.Options.UserAgent)
 	}
+	result := verify.Score(baseline, page)
 
-	// CenQL query: match hosts that present the domain in their DNS names or in
-	// a served TLS certificate's leaf names (including subdomains).
-	query := fmt.Sprintf(`host.dns.names: "%s" or host.dns.names: "*.%s" or host.services.tls.certificates.leaf_data.names: "%s"`, domain, domain, domain)
+	certs := httpClient.GetCertificates(ctx, ip.String(), hostHeader, certEndpoints)
+	result = verify.Merge(result, verify.ScoreCertificates(baseline, certs))
+
+	return page, result, result.Score >= s.Options.Threshold
+}
 
-	var stats struct {
-		totalFound       int
-		cloudflareIPs    int
-		nonCloudflareIPs int
+// newFinding describes a verified origin. page may be nil when the origin was
+// confirmed by its certificate alone.
+func newFinding(url string, cfIP net.IP, candidate models.Candidate, page *httpClient.Page, result verify.Result) models.Finding {
+	finding := models.Finding{
+		Type:         "finding",
+		URL:          url,
+		CloudflareIP: cfIP.String(),
+		OriginIP:     candidate.IP.String(),
+		Source:       candidate.Source,
+		Score:        result.Score,
+		Signals:      result.Signals,
+		Evidence:     candidate.Evidence,
+		Timestamp:    time.Now().UTC(),
 	}
+	if page != nil {
+		finding.Scheme = page.Scheme
+		finding.Port, _ = strconv.Atoi(page.Port)
+		finding.Title = page.Title
+	}
+	return finding
+}
 
-	if !s.Options.Verbose {
-		color.Cyan("\n[*] Censys search for %s started.", domain)
+// getBaseline builds the comparison baseline for a target from the page at
+// its path, and returns it along with the URL it was fetched from. A target
+// without a scheme is fetched over HTTPS first and over HTTP when that fails.
+// A title given with -title replaces the fetched page entirely, since the
+// page is then assumed to be a Cloudflare challenge. The favicon hash and the
+// certificate Cloudflare serves are recorded in either case.
+func (s *Scanner) getBaseline(ctx context.Context, t target.Target) (*verify.Baseline, string) {
+	host := t.Host
+	urls := t.URLs()
+	urlStr := urls[0]
+
+	var page *httpClient.Page
+	var baseline *verify.Baseline
+	if s.Options.Title != "" {
+		baseline = verify.NewBaseline(host, nil, s.Options.Title)
 	} else {
-		color.Cyan("\n[*] Censys search results for %s:", domain)
+		for _, u := range urls {
+			var err error
+			if page, err = httpClient.GetPage(ctx, u, s.Options.HTTPMethod, s.Options.JA3, s.Options.UserAgent, s.Options.Proxy); err == nil {
+				urlStr = u
+				break
+			}
+		}
+		baseline = verify.NewBaseline(host, page, "")
+	}
+
+	if !baseline.Challenge {
+		client := httpClient.NewTLSClient("", "", s.Options.UserAgent, s.Options.Proxy)
+		baseline.Favicon, _ = httpClient.GetFaviconHash(ctx, client, httpClient.FaviconURLs(urlStr, page), "", host, s.Options.UserAgent)
 	}
 
-	client := httpClient.NewHTTPClient(s.Options.Proxy, url)
-	pageToken := ""
+	certPort := "443"
+	if t.Port != "" && strings.HasPrefix(urlStr, "https://") {
+		certPort = t.Port
+	}
+	if cert, err := httpClient.GetCertificate(ctx, host, certPort, host); err == nil {
+		baseline.AddCertificate(cert)
+	}
+	return baseline, urlStr
+}
 
-	for {
-		requestBody := map[string]interface{}{
-			"query":     query,
-			"page_size": 100,
+func (s *Scanner) checkARecords(ctx context.Context, run *sourceRun, ips []net.IP, cfIP net.IP, baseline *verify.Baseline) {
+	for _, ip := range ips {
+		candidate := models.Candidate{IP: ip, Source: "A - Record"}
+		if !s.discovered(run, candidate) {
+			continue
 		}
-		if pageToken != "" {
-			requestBody["page_token"] = pageToken
+		if s.Options.Verbose {
+			s.logf(models.LevelNotice, "[*] Non-Cloudflare IP(%s) found in %s's A/AAAA records. Checking it...", ip.String(), run.url)
 		}
-		jsonBody, err := json.Marshal(requestBody)
-		if err != nil {
-			color.Yellow("[!] Error preparing Censys request body: %v", err)
-			return
+		s.compareTitle(ctx, run, candidate, cfIP, baseline)
+	}
+}
+
+// checkSPF verifies every address the target's SPF policy authorizes. Each
+// finding names the chain of mechanisms that produced the address.
+func (s *Scanner) checkSPF(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	result, err := dns.ResolveSPF(ctx, domain, s.Options.SPFExpand)
+	if result == nil {
+		return
+	}
+	if err != nil && s.Options.Verbose {
+		s.logf(models.LevelWarning, "[!] %s's SPF record could not be fully resolved: %v", domain, err)
+	}
+
+	if s.Options.Verbose {
+		for _, network := range result.Networks {
+			s.logf(models.LevelInfo, "[*] SPF block %s of %s (%s) is larger than -spf-expand. Skipping...", network.Network, domain, network.Path)
 		}
+	}
 
-		req, err := http.NewRequest("POST", censysURL, strings.NewReader(string(jsonBody)))
-		if err != nil {
-			color.Yellow("[!] Error creating Censys request: %v", err)
-			return
+	for _, address := range result.Addresses {
+		candidate := models.Candidate{IP: address.IP, Source: "SPF - " + address.Path}
+		if !s.discovered(run, candidate) {
+			continue
 		}
-		req.Header.Set("Authorization", "Bearer "+pat)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("Accept", "application/json")
-		req.Header.Set("User-Agent", s.Options.UserAgent)
-		if orgID != "" {
-			req.Header.Set("X-Organization-ID", orgID)
+		if s.Options.Verbose {
+			s.logf(models.LevelNotice, "[*] Non-Cloudflare IP(%s) found in %s's SPF record (%s). Checking it...", address.IP, domain, address.Path)
 		}
+		s.compareTitle(ctx, run, candidate, cfIP, baseline)
+	}
+}
 
-		resp, err := client.Do(req)
-		if err != nil {
-			if strings.Contains(err.Error(), "giving up after") {
-				color.Yellow("[!] Censys API rate limit exceeded. Please try again later. (%s)", domain)
-			} else {
-				color.Yellow("[!] Error making request to Censys API: %v", err)
-			}
-			return
-		}
+// checkMXRecords verifies the addresses of the target's mail exchangers,
+// which Cloudflare never proxies and which often share a host or network with
+// the origin. With -smtp-banner the hostname each server announces is kept as
+// evidence.
+func (s *Scanner) checkMXRecords(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	hosts, err := dns.GetMXRecords(ctx, domain)
+	if err != nil {
+		return
+	}
 
-		// Check response status
-		if resp.StatusCode != 200 {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			var errorResponse struct {
-				Message string `json:"message"`
-				Detail  string `json:"detail"`
+	for _, host := range hosts {
+		for _, ip := range host.IPs {
+			candidate := models.Candidate{IP: ip, Source: "MX - " + host.Host}
+			if !s.discovered(run, candidate) {
+				continue
 			}
-			msg := strings.TrimSpace(string(bodyBytes))
-			if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
-				if errorResponse.Message != "" {
-					msg = errorResponse.Message
-				} else if errorResponse.Detail != "" {
-					msg = errorResponse.Detail
+			if s.Options.SMTPBanner {
+				if banner, err := dns.SMTPBanner(ctx, ip); err == nil {
+					candidate.Evidence = append(candidate.Evidence, "SMTP banner: "+banner)
 				}
 			}
-			switch resp.StatusCode {
-			case 401:
-				color.Yellow("[!] Censys API authentication failed (401). Check your Personal Access Token. %s", msg)
-			case 403:
-				color.Yellow("[!] Censys API access denied (403). A paid plan and Organization ID may be required. %s", msg)
-			case 429:
-				color.Yellow("[!] Censys API rate limit exceeded (429): %s", msg)
-			default:
-				color.Yellow("[!] Censys API returned non-200 status code %d: %s", resp.StatusCode, msg)
+			if s.Options.Verbose {
+				s.logf(models.LevelNotice, "[*] Non-Cloudflare IP(%s) found in %s's MX record (%s). Checking it...", ip, domain, host.Host)
+				for _, evidence := range candidate.Evidence {
+					s.logf(models.LevelInfo, "[*]   %s", evidence)
+				}
 			}
-			return
+			s.compareTitle(ctx, run, candidate, cfIP, baseline)
 		}
+	}
+}
 
-		var data models.CensysPlatformResponse
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			resp.Body.Close()
-			color.Yellow("[!] Error decoding Censys response: %v", err)
-			return
+// checkZoneTransfer tries AXFR against every nameserver of the target's
+// zone. The addresses a leaked zone holds, and those of the names its records
+// point at, are verified as origins of the target when they lie outside
+// Cloudflare's ranges. Each candidate is tagged with the name it belongs to.
+func (s *Scanner) checkZoneTransfer(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	zone, nameservers, err := dns.GetNameservers(ctx, domain)
+	if err != nil {
+		return
+	}
+
+	tried := make(map[string]bool)
+	checked := make(map[string]bool)
+	for _, ns := range nameservers {
+		// One attempt per server, over its first address.
+		if tried[ns.Host] || ctx.Err() != nil {
+			continue
+		}
+		tried[ns.Host] = true
+
+		transfer := dns.TransferZone(ctx, zone, ns)
+		record := models.ZoneTransfer{
+			Domain:     domain,
+			Zone:       zone,
+			Nameserver: ns.Host,
+			Success:    transfer.Records > 0,
+			Records:    transfer.Records,
+		}
+		if !record.Success && transfer.Err != nil {
+			record.Error = transfer.Err.Error()
+		}
+		s.mu.Lock()
+		s.Stats.AXFRAttempted++
+		if record.Success {
+			s.Stats.AXFRSucceeded++
 		}
-		resp.Body.Close()
+		s.zoneTransfers = append(s.zoneTransfers, record)
+		s.mu.Unlock()
 
-		for _, hit := range data.Result.Hits {
-			if hit.HostV1 == nil {
-				continue
-			}
-			censysIP := net.ParseIP(hit.HostV1.Resource.IP)
-			if censysIP == nil || censysIP.To4() == nil {
-				continue
-			}
-			stats.totalFound++
-			result, _ := dns.IsInCloudflareIPRange(censysIP)
+		if !record.Success {
 			if s.Options.Verbose {
-				if result {
-					color.White("[+] IP: %s (Cloudflare)", censysIP)
-				} else {
-					color.Yellow("[+] IP: %s", censysIP)
-				}
-			}
-			if result {
-				stats.cloudflareIPs++
-			} else {
-				stats.nonCloudflareIPs++
-				s.compareTitle(url, censysIP, cfIP, "Censys", actualHTMLTitle)
+				s.logf(models.LevelInfo, "[-] Zone transfer of %s from %s failed: %s", zone, ns.Host, record.Error)
 			}
+			continue
 		}
+		s.logf(models.LevelNotice, "[*] Zone transfer of %s from %s succeeded (%d records, %d hosts with addresses).", zone, ns.Host, transfer.Records, len(transfer.Hosts))
 
-		if data.Result.NextPageToken == "" {
-			break
+		for _, host := range transfer.Hosts {
+			for _, ip := range host.IPs {
+				if checked[ip.String()] {
+					continue
+				}
+				checked[ip.String()] = true
+				candidate := models.Candidate{IP: ip, Source: "AXFR - " + host.Host + " (" + ns.Host + ")"}
+				if !s.discovered(run, candidate) {
+					continue
+				}
+				if s.Options.Verbose {
+					s.logf(models.LevelNotice, "[*] Non-Cloudflare IP(%s) found for %s in the transferred zone. Checking it...", ip, host.Host)
+				}
+				s.compareTitle(ctx, run, candidate, cfIP, baseline)
+			}
 		}
-		pageToken = data.Result.NextPageToken
-	}
-
-	if !s.Options.Verbose {
-		color.Cyan("[*] Censys search for %s completed. (Total %d IPs Found, %d IPs don't belong to Cloudflare)",
-			domain, stats.totalFound, stats.nonCloudflareIPs)
 	}
 }
 
-func (s *Scanner) securityTrailsSearch(domain, url string, cfIP net.IP, actualHTMLTitle string) {
-	keys := config.ReadAPIKeys("securitytrails")
-	if len(keys) == 0 {
-		color.Yellow("[!] SecurityTrails API key not found in ~/.config/cf-hero.yaml")
+// checkAuthoritative asks each authoritative nameserver of the target's zone
+// directly for its records and verifies the addresses that the servers, or
+// the recursive resolvers, disagree on.
+func (s *Scanner) checkAuthoritative(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	zone, nameservers, err := dns.GetNameservers(ctx, domain)
+	if err != nil {
+		if s.Options.Verbose {
+			s.logf(models.LevelWarning, "[!] Could not find the nameservers of %s: %v", domain, err)
+		}
 		return
 	}
-	key := keys[0]
-	if key == "" {
-		color.Yellow("[!] SecurityTrails API key is empty in ~/.config/cf-hero.yaml")
-		return
+	if s.Options.Verbose {
+		var hosts []string
+		for _, ns := range nameservers {
+			hosts = append(hosts, fmt.Sprintf("%s (%s)", ns.Host, ns.IP))
+		}
+		s.logf(models.LevelNotice, "\n[*] Authoritative nameservers of %s: %s", zone, strings.Join(hosts, ", "))
 	}
 
-	apiURL := fmt.Sprintf("https://api.securitytrails.com/v1/history/%s/dns/a", domain)
-	client := httpClient.NewHTTPClient(s.Options.Proxy, url)
-
-	req := httpClient.RequestBuilder(apiURL, "", s.Options.HTTPMethod, s.Options.UserAgent)
-	req.Header.Set("APIKEY", key)
-	req.Header.Set("Accept", "application/json")
-
-	resp, err := client.Do(req)
+	addresses, err := dns.CompareAuthoritative(ctx, domain, nameservers)
 	if err != nil {
-		if strings.Contains(err.Error(), "giving up after") {
-			color.Yellow("[!] SecurityTrails API rate limit exceeded. Please try again later. (%s)", domain)
-		} else {
-			color.Yellow("[!] Error making request to SecurityTrails API: %v", err)
-		}
+		s.logf(models.LevelWarning, "[!] Error querying the nameservers of %s: %v", domain, err)
 		return
 	}
-	defer resp.Body.Close()
 
-	// Check response status
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		var errorResponse struct {
-			Message string `json:"message"`
+	for _, address := range addresses {
+		candidate := models.Candidate{
+			IP:       address.IP,
+			Source:   fmt.Sprintf("Authoritative - %s (%s)", address.Record, strings.Join(address.Nameservers, ", ")),
+			Evidence: []string{address.Reason},
 		}
-		if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
-			if strings.Contains(errorResponse.Message, "exceeded the usage limits") {
-				color.Yellow("[!] SecurityTrails API rate limit exceeded: %s", errorResponse.Message)
-			} else {
-				color.Yellow("[!] SecurityTrails API error: %s", errorResponse.Message)
-			}
-		} else {
-			color.Yellow("[!] SecurityTrails API returned non-200 status code %d: %s", resp.StatusCode, string(bodyBytes))
+		if !s.discovered(run, candidate) {
+			continue
 		}
-		color.Yellow("[!] HTTP Status: %s", resp.Status)
-		color.Yellow("[!] HTTP Headers:")
-		for key, values := range resp.Header {
-			for _, value := range values {
-				color.Yellow("[!]   %s: %s", key, value)
-			}
+		if s.Options.Verbose {
+			s.logf(models.LevelNotice, "[*] Non-Cloudflare IP(%s) found in %s from %s (%s). Checking it...",
+				address.IP, address.Record, strings.Join(address.Nameservers, ", "), address.Reason)
 		}
-		return
-	}
-
-	var data models.SecurityTrailsResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		color.Yellow("[!] Error decoding SecurityTrails response: %v", err)
-		return
-	}
-
-	var stats struct {
-		totalFound       int
-		cloudflareIPs    int
-		nonCloudflareIPs int
+		s.compareTitle(ctx, run, candidate, cfIP, baseline)
 	}
+}
 
+// checkSubdomains resolves the wordlist under the target and verifies every
+// non-Cloudflare address as an origin of the target itself. An address shared
+// by several subdomains is verified once.
+func (s *Scanner) checkSubdomains(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
 	if !s.Options.Verbose {
-		color.Cyan("\n[*] SecurityTrails  DNS records for %s started.", domain)
+		s.logf(models.LevelNotice, "\n[*] Subdomain brute force for %s started.", domain)
 	} else {
-		color.Cyan("\n[*] SecurityTrails  DNS records for %s:", domain)
+		s.logf(models.LevelNotice, "\n[*] Subdomain brute force results for %s:", domain)
 	}
 
-	for _, record := range data.Records {
-		org := "Unknown"
-		if len(record.Organizations) > 0 {
-			org = strings.Join(record.Organizations, ", ")
-		}
-		period := fmt.Sprintf("%s to %s", record.FirstSeen, record.LastSeen)
+	subdomains, err := dns.BruteForce(ctx, domain, s.Wordlist, subdomainConcurrency)
+	if err != nil {
+		s.logf(models.LevelWarning, "[!] Error brute forcing subdomains of %s: %v", domain, err)
+		return
+	}
 
-		for _, value := range record.Values {
-			ip := net.ParseIP(value.IP)
-			if ip != nil && ip.To4() != nil {
-				stats.totalFound++
-				result, _ := dns.IsInCloudflareIPRange(ip)
-				if s.Options.Verbose {
-					if result {
-						color.White("[+] [IP: %s - Organization: %s - Period: %s] (Cloudflare)", value.IP, org, period)
-					} else {
-						color.Yellow("[+] [IP: %s - Organization: %s - Period: %s]", value.IP, org, period)
-					}
-				}
-				if result {
-					stats.cloudflareIPs++
+	checked := make(map[string]bool)
+	for _, subdomain := range subdomains {
+		for _, ip := range subdomain.IPs {
+			isCloudflare, _ := dns.IsInCloudflareIPRange(ip)
+			if s.Options.Verbose {
+				if isCloudflare {
+					s.logf(models.LevelInfo, "[+] %s: %s (Cloudflare)", subdomain.Host, ip)
 				} else {
-					stats.nonCloudflareIPs++
-					s.compareTitle(url, ip, cfIP, "SecurityTrails", actualHTMLTitle)
+					s.logf(models.LevelWarning, "[+] %s: %s", subdomain.Host, ip)
 				}
 			}
+			if checked[ip.String()] {
+				continue
+			}
+			checked[ip.String()] = true
+			candidate := models.Candidate{IP: ip, Source: "Subdomain: " + subdomain.Host}
+			if s.discovered(run, candidate) {
+				s.compareTitle(ctx, run, candidate, cfIP, baseline)
+			}
 		}
 	}
 
 	if !s.Options.Verbose {
-		color.Cyan("[*] SecurityTrails DNS records for %s completed. (Total %d IPs Found, %d IPs don't belong to Cloudflare)",
-			domain, stats.totalFound, stats.nonCloudflareIPs)
+		s.logf(models.LevelNotice, "[*] Subdomain brute force for %s completed. (%d subdomains resolved, %d IPs don't belong to Cloudflare)",
+			domain, len(subdomains), run.nonCloudflare)
 	}
 }
 
-func (s *Scanner) shodanSearch(domain, url string, cfIP net.IP, actualHTMLTitle string) {
-	keys := config.ReadAPIKeys("shodan")
-	if len(keys) == 0 {
-		color.Yellow("[!] Shodan API key not found in ~/.config/cf-hero.yaml")
-		return
-	}
-	key := keys[0]
-	if key == "" {
-		color.Yellow("[!] Shodan API key is empty in ~/.config/cf-hero.yaml")
-		return
+// runSource drains the candidates of a source, verifying every one outside
+// Cloudflare's ranges.
+func (s *Scanner) runSource(ctx context.Context, run *sourceRun, discover func(ctx context.Context) <-chan models.Candidate, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	name := run.name
+	candidates := s.sourceCandidates(ctx, run, discover)
+	if !s.Options.Verbose {
+		s.logf(models.LevelNotice, "\n[*] %s search for %s started.", name, domain)
+	} else {
+		s.logf(models.LevelNotice, "\n[*] %s search results for %s:", name, domain)
 	}
 
-	maxRetries := 3
-	retryCount := 0
-	var resp *http.Response
-	var err error
-
-	apiURL := fmt.Sprintf("https://api.shodan.io/dns/domain/%s?key=%s&history=true", domain, key)
-	client := httpClient.NewHTTPClient(s.Options.Proxy, url)
-	req := httpClient.RequestBuilder(apiURL, "", s.Options.HTTPMethod, s.Options.UserAgent)
-	req.Header.Set("Accept", "application/json")
-
-	// Retry loop
-	for retryCount < maxRetries {
-		resp, err = client.Do(req)
-		if err == nil && resp.StatusCode == 200 {
-			break
-		}
-
-		retryCount++
-		if retryCount == maxRetries {
-			// Retries exhausted. A transport error has no usable response, so
-			// report it and bail. A non-200 response is left open so the status
-			// handler below can surface the real reason (e.g. plan/credits).
-			if err != nil {
-				if resp != nil {
-					resp.Body.Close()
-				}
-				color.Red("[-] Error making request to Shodan API after %d retries: %v", maxRetries, err)
-				return
+	for candidate := range candidates {
+		verifiable := s.discovered(run, candidate)
+		if s.Options.Verbose {
+			detail := ""
+			if candidate.Detail != "" {
+				detail = " (" + candidate.Detail + ")"
+			}
+			if !verifiable {
+				s.logf(models.LevelInfo, "[+] IP: %s%s (Cloudflare)", candidate.IP, detail)
+			} else {
+				s.logf(models.LevelWarning, "[+] IP: %s%s", candidate.IP, detail)
 			}
-			break
 		}
-
-		if resp != nil {
-			resp.Body.Close()
+		if verifiable {
+			s.compareTitle(ctx, run, candidate, cfIP, baseline)
 		}
+	}
 
-		// Exponential backoff: 1s, 2s, 4s, 8s, 16s
-		waitTime := time.Duration(1<<uint(retryCount-1)) * time.Second
-		color.Yellow("[!] Retrying Shodan API request in %v (attempt %d/%d)...", waitTime, retryCount, maxRetries)
-		time.Sleep(waitTime)
+	if !s.Options.Verbose {
+		s.logf(models.LevelNotice, "[*] %s search for %s completed. (Total %d IPs Found, %d IPs don't belong to Cloudflare)",
+			name, domain, run.found, run.nonCloudflare)
 	}
-	defer resp.Body.Close()
+}
 
-	// Check response status
-	if resp.StatusCode != 200 {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		var errorResponse struct {
-			Error string `json:"error"`
+// sourceCandidates returns the candidates of a passive source: those saved in
+// the checkpoint by a previous run, or those it discovers now. A discovery
+// that completes without error is saved for later runs, so the API is not
+// queried again. Candidates are saved along with the inputs of the search,
+// e.g. the number of favicon result pages, and searched again when they
+// change.
+func (s *Scanner) sourceCandidates(ctx context.Context, run *sourceRun, discover func(ctx context.Context) <-chan models.Candidate) <-chan models.Candidate {
+	name := run.name
+	if run.params != "" {
+		name += " (" + run.params + ")"
+	}
+	if cached, ok := s.Checkpoint.Candidates(run.target, name); ok {
+		if s.Options.Verbose {
+			s.logf(models.LevelInfo, "[*] Reusing %d candidate(s) of %s saved in the checkpoint.", len(cached), run.name)
 		}
-		if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil && errorResponse.Error != "" {
-			color.Yellow("[!] Shodan API error (%d): %s", resp.StatusCode, errorResponse.Error)
-		} else {
-			color.Yellow("[!] Shodan API returned non-200 status code %d: %s", resp.StatusCode, string(bodyBytes))
+		ch := make(chan models.Candidate, len(cached))
+		for _, candidate := range cached {
+			ch <- candidate
 		}
-		return
+		close(ch)
+		return ch
 	}
-
-	var data models.ShodanDNSHistoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-		color.Yellow("[!] Error decoding Shodan response: %v\n", err)
-		return
+	if s.Checkpoint == nil {
+		return discover(ctx)
 	}
 
-	var stats struct {
-		totalFound       int
-		cloudflareIPs    int
-		nonCloudflareIPs int
-	}
+	sourceCtx, failed := sources.WithFailures(ctx)
+	discovered := discover(sourceCtx)
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		var all []models.Candidate
+		for candidate := range discovered {
+			all = append(all, candidate)
+			ch <- candidate
+		}
+		run.failed = failed()
+		if ctx.Err() == nil && !run.failed {
+			s.record(s.Checkpoint.SaveCandidates(run.target, name, all))
+		}
+	}()
+	return ch
+}
 
+// checkCandidates verifies every user supplied and imported host against the
+// target, skipping those in Cloudflare's ranges.
+func (s *Scanner) checkCandidates(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
 	if s.Options.Verbose {
-		color.Cyan("\n[*] Shodan DNS records for %s started.", domain)
+		s.logf(models.LevelNotice, "\n[*] Checking %d candidate host(s) against %s...", len(s.Candidates), domain)
 	}
+	s.compareAll(ctx, run, s.Candidates, cfIP, baseline)
+}
 
-	for _, record := range data.Data {
-		if record.Type == "A" {
-			ip := net.ParseIP(record.Value)
-			if ip != nil && ip.To4() != nil {
-				stats.totalFound++
-				result, _ := dns.IsInCloudflareIPRange(ip)
-				if s.Options.Verbose {
-					if result {
-						color.White("[+] IP: %s (Last seen: %s) (Cloudflare)",
-							record.Value, record.LastSeen)
-					} else {
-						color.Yellow("[+] IP: %s (Last seen: %s)",
-							record.Value, record.LastSeen)
-					}
-				}
-				if result {
-					stats.cloudflareIPs++
-				} else {
-					stats.nonCloudflareIPs++
-					s.compareTitle(url, ip, cfIP, "Shodan", actualHTMLTitle)
-				}
+// checkNeighbors verifies the neighbors queued for the target while its other
+// candidates were checked. Origins found among them are not expanded further.
+func (s *Scanner) checkNeighbors(ctx context.Context, run *sourceRun, domain string, cfIP net.IP, baseline *verify.Baseline) {
+	// Neighbors verified by another technique after they were queued are
+	// not verified again.
+	s.mu.Lock()
+	var pending []models.Candidate
+	if queue := s.neighbors[run.url]; queue != nil {
+		for _, candidate := range queue.pending {
+			if !queue.checked[candidate.IP.String()] {
+				pending = append(pending, candidate)
 			}
 		}
+		queue.pending = nil
 	}
+	s.mu.Unlock()
 
-	if !s.Options.Verbose {
-		color.Cyan("[*] Shodan DNS records for %s completed. (Total %d IPs Found, %d IPs don't belong to Cloudflare)",
-			domain, stats.totalFound, stats.nonCloudflareIPs)
-	}
-}
-
-func (s *Scanner) zoomeyeSearch(domain, url string, cfIP net.IP, actualHTMLTitle string) {
-	keys := config.ReadAPIKeys("zoomeye")
-	if len(keys) == 0 {
-		color.Yellow("[!] ZoomEye API key not found in ~/.config/cf-hero.yaml")
-		return
-	}
-	key := keys[0]
-	if key == "" {
-		color.Yellow("[!] ZoomEye API key is empty in ~/.config/cf-hero.yaml")
+	if len(pending) == 0 {
 		return
 	}
-
-	// Base64 encode the query. The domain value must be quoted per ZoomEye's
-	// dork syntax, otherwise values containing dots may be parsed incorrectly.
-	query := fmt.Sprintf(`domain="%s"`, domain)
-	queryBase64 := base64.StdEncoding.EncodeToString([]byte(query))
-
-	page := 1
-	resultsPerPage := 100
-	var totalResults int
-	var stats struct {
-		totalFound       int
-		cloudflareIPs    int
-		nonCloudflareIPs int
-		testedIPs        int
+	if s.Options.Verbose {
+		s.logf(models.LevelNotice, "\n[*] Checking %d neighbor(s) of the origins of %s...", len(pending), domain)
 	}
+	s.compareAll(ctx, run, pending, cfIP, baseline)
+}
 
-	for {
-		// Prepare request body
-		requestBody := map[string]interface{}{
-			"qbase64":  queryBase64,
-			"page":     page,
-			"pagesize": resultsPerPage,
-		}
-		jsonBody, err := json.Marshal(requestBody)
-		if err != nil {
-			color.Red("[-] Error preparing ZoomEye request body: %v\n", err)
-			return
-		}
-
-		zoomeyeURL := "https://api.zoomeye.ai/v2/search"
-		client := httpClient.NewHTTPClient(s.Options.Proxy, url)
-
-		req, err := http.NewRequest("POST", zoomeyeURL, strings.NewReader(string(jsonBody)))
-		if err != nil {
-			color.Red("[-] Error creating ZoomEye request: %v\n", err)
-			return
-		}
-
-		req.Header.Set("API-KEY", key)
-		req.Header.Set("Content-Type", "application/json")
-		req.Header.Set("User-Agent", s.Options.UserAgent)
-
-		resp, err := client.Do(req)
-		if err != nil {
-			color.Red("[-] Error making request to ZoomEye API: %v\n", err)
-			return
-		}
-
-		// Check response status
-		if resp.StatusCode != 200 {
-			bodyBytes, _ := io.ReadAll(resp.Body)
-			resp.Body.Close()
-			color.Yellow("[!] ZoomEye API returned non-200 status code %d: %s\n", resp.StatusCode, string(bodyBytes))
-			return
+// hasPendingNeighbors reports whether neighbors of the origins of url wait to
+// be verified.
+func (s *Scanner) hasPendingNeighbors(url string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if queue := s.neighbors[url]; queue != nil {
+		for _, candidate := range queue.pending {
+			if !queue.checked[candidate.IP.String()] {
+				return true
+			}
 		}
+	}
+	return false
+}
 
-		var data models.ZoomeyeResponse
-		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
-			resp.Body.Close()
-			color.Red("[-] Error decoding ZoomEye response: %v\n", err)
-			return
+// queueNeighbors queues the addresses around a confirmed origin of url that
+// have been neither queued nor verified yet.
+func (s *Scanner) queueNeighbors(url string, origin net.IP) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queue := s.neighborQueue(url)
+	queue.seen[origin.String()] = true
+	for _, ip := range candidates.Neighbors(origin, s.Options.Neighbors, s.Options.MaxNeighbors) {
+		if !queue.seen[ip.String()] && !queue.checked[ip.String()] {
+			queue.seen[ip.String()] = true
+			queue.pending = append(queue.pending, models.Candidate{IP: ip, Source: neighborSource + origin.String()})
 		}
-		resp.Body.Close()
+	}
+}
 
-		// ZoomEye signals API-level errors (quota, auth, bad query) with a
-		// non-60000 code even on an HTTP 200 response.
-		if data.Code != 60000 {
-			color.Yellow("[!] ZoomEye API error (code %d): %s", data.Code, data.Message)
-			return
-		}
+// neighborQueue returns the neighbor queue of url, creating it if needed. The
+// caller holds s.mu.
+func (s *Scanner) neighborQueue(url string) *neighborQueue {
+	queue := s.neighbors[url]
+	if queue == nil {
+		queue = &neighborQueue{seen: make(map[string]bool), checked: make(map[string]bool)}
+		s.neighbors[url] = queue
+	}
+	return queue
+}
 
-		// Set total results on first page
-		if page == 1 {
-			totalResults = data.Total
-			if s.Options.Verbose {
-				color.Cyan("\n[*] ZoomEye search results for %s (Total: %d):", domain, totalResults)
+// compareAll verifies candidates in parallel, skipping those in Cloudflare's
+// ranges.
+func (s *Scanner) compareAll(ctx context.Context, run *sourceRun, list []models.Candidate, cfIP net.IP, baseline *verify.Baseline) {
+	var wg sync.WaitGroup
+	jobs := make(chan models.Candidate)
+	for i := 0; i < candidateConcurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for candidate := range jobs {
+				s.compareTitle(ctx, run, candidate, cfIP, baseline)
 			}
+		}()
+	}
+	for _, candidate := range list {
+		if ctx.Err() != nil {
+			break
 		}
-
-		s.mu.Lock()
-		s.Stats.TotalIPsScanned += len(data.Data)
-		s.mu.Unlock()
-
-		// Process results for current page
-		for _, result := range data.Data {
-			zoomeyeIP := net.ParseIP(result.IP)
-			if zoomeyeIP.To4() != nil {
-				stats.totalFound++
-
-				// Convert port from json.RawMessage to int
-				var port int
-				if err := json.Unmarshal(result.Port, &port); err != nil {
-					// Try to unmarshal as string first
-					var portStr string
-					if err := json.Unmarshal(result.Port, &portStr); err != nil {
-						color.Red("[-] Error converting port %s to int: %v\n", string(result.Port), err)
-						continue
-					}
-					// Convert string to int
-					port, err = strconv.Atoi(portStr)
-					if err != nil {
-						color.Red("[-] Error converting port string %s to int: %v\n", portStr, err)
-						continue
-					}
-				}
-
-				isCloudflare, _ := dns.IsInCloudflareIPRange(zoomeyeIP)
-				if s.Options.Verbose {
-					if isCloudflare {
-						color.White("[+] IP: %s (Port: %d, Domain: %s, Updated: %s) (Cloudflare)",
-							result.IP, port, result.Domain, result.UpdateTime)
-					} else {
-						color.Yellow("[+] IP: %s (Port: %d, Domain: %s, Updated: %s)",
-							result.IP, port, result.Domain, result.UpdateTime)
-					}
-				}
-				if isCloudflare {
-					stats.cloudflareIPs++
-				} else {
-					stats.nonCloudflareIPs++
-					stats.testedIPs++
-					s.compareTitle(url, zoomeyeIP, cfIP, "ZoomEye", actualHTMLTitle)
-				}
+		if !s.discovered(run, candidate) {
+			if s.Options.Verbose {
+				s.logf(models.LevelInfo, "[-] %s is a Cloudflare IP. Skipping...", candidate.IP)
 			}
+			continue
 		}
+		jobs <- candidate
+	}
+	close(jobs)
+	wg.Wait()
+}
 
-		// Stop when the API returns a short/empty page or we've covered every
-		// reported result. Guarding on the returned page length avoids an
-		// infinite loop if the reported total is inaccurate.
-		if len(data.Data) < resultsPerPage || page*resultsPerPage >= totalResults {
-			break
+// compareTitle verifies a candidate of run as an origin of its target and
+// reports it when it matches. The ports the candidate carries are probed in
+// addition to the configured ones.
+func (s *Scanner) compareTitle(ctx context.Context, run *sourceRun, candidate models.Candidate, cfIP net.IP, baseline *verify.Baseline) {
+	url := run.url
+	if ctx.Err() != nil {
+		return
+	}
+	page, result, ok := s.verifyCandidate(ctx, url, candidate, baseline)
+	if !ok && ctx.Err() != nil {
+		// Cut short: the candidate was neither verified nor rejected.
+		return
+	}
+	s.mu.Lock()
+	s.Stats.TotalIPsScanned++
+	if s.Options.Neighbors > 0 {
+		s.neighborQueue(url).checked[candidate.IP.String()] = true
+	}
+	s.mu.Unlock()
+
+	event := models.Event{
+		Type:      models.EventCandidateVerified,
+		Target:    run.target,
+		URL:       url,
+		Source:    run.name,
+		Candidate: &candidate,
+		Score:     result.Score,
+		Signals:   result.Signals,
+	}
+	if !ok {
+		event.Type = models.EventCandidateRejected
+		event.Reason = s.rejection(page, result)
+		s.publish(event)
+		if s.Options.Verbose {
+			s.logf(models.LevelInfo, "[-] %s scored %d/100 for %s (Source: %s). Skipping...", candidate.IP, result.Score, url, candidate.Source)
 		}
-		page++
+		return
 	}
+	s.publish(event)
 
-	if !s.Options.Verbose {
-		color.Cyan("[*] ZoomEye search for %s completed. (Total %d IPs Found, %d IPs don't belong to Cloudflare)",
-			domain, stats.totalFound, stats.nonCloudflareIPs)
+	s.mu.Lock()
+	s.Stats.RealIPsFound++
+	s.mu.Unlock()
+	s.report(run, newFinding(url, cfIP, candidate, page, result))
+
+	if !strings.HasPrefix(candidate.Source, neighborSource) {
+		s.record(s.Checkpoint.AddOrigin(run.target, candidate.IP.String()))
+		if s.Options.Neighbors > 0 {
+			s.queueNeighbors(url, candidate.IP)
+		}
 	}
 }
 
-func (s *Scanner) compareTitle(url string, ip net.IP, cfIP net.IP, source string, actualHTMLTitle string) {
-	foundIPTitle, _ := httpClient.GetHTMLTitleWithPortCheck(ip.String(), s.Options.JA3, s.Options.UserAgent, s.Options.Proxy)
-
-	if actualHTMLTitle == foundIPTitle {
-		s.mu.Lock()
-		s.Stats.RealIPsFound++
-		s.mu.Unlock()
-		s.printResult(url, cfIP, ip, source, actualHTMLTitle)
+// rejection explains why a candidate scored below the threshold.
+func (s *Scanner) rejection(page *httpClient.Page, result verify.Result) string {
+	if page == nil && result.Score == 0 {
+		return "no HTTP response and no matching certificate"
 	}
+	return fmt.Sprintf("scored %d/100, below the threshold of %d", result.Score, s.Options.Threshold)
 }
 
-func (s *Scanner) printResult(url string, cfIP net.IP, realIP interface{}, source, htmlTitle string) {
-	color.Green("[+] Found real IP of %s : %v (Source: %s) - Title: %s", url, realIP, source, htmlTitle)
+// report publishes a verified origin.
+func (s *Scanner) report(run *sourceRun, finding models.Finding) {
+	s.publish(models.Event{Type: models.EventFinding, Target: run.target, URL: run.url, Source: run.name, Finding: &finding})
 }
@@ -0,0 +1,173 @@
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	miekg "github.com/miekg/dns"
+	"github.com/musana/cf-hero/internal/checkpoint"
+	"github.com/musana/cf-hero/internal/dns"
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// serveZone answers A queries for host with a Cloudflare address, and every
+// other query with an empty answer, on a local resolver every lookup is
+// pointed at.
+func serveZone(t *testing.T, host string) {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skipf("cannot listen on UDP: %v", err)
+	}
+	server := &miekg.Server{PacketConn: conn, Handler: miekg.HandlerFunc(func(w miekg.ResponseWriter, req *miekg.Msg) {
+		resp := new(miekg.Msg)
+		resp.SetReply(req)
+		for _, q := range req.Question {
+			if strings.EqualFold(q.Name, host+".") && q.Qtype == miekg.TypeA {
+				rr, _ := miekg.NewRR(host + ". 60 IN A 104.16.0.1")
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+		w.WriteMsg(resp)
+	})}
+	go server.ActivateAndServe()
+	t.Cleanup(func() {
+		server.Shutdown()
+		dns.Configure(dns.DefaultResolvers, dns.DefaultRetries, dns.DefaultTimeout)
+	})
+	if err := dns.Configure([]string{conn.LocalAddr().String()}, 1, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// events records the events of a scan.
+type events struct {
+	mu   sync.Mutex
+	list []models.Event
+}
+
+func (e *events) add(event models.Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.list = append(e.list, event)
+}
+
+// verified returns the candidates verified or rejected by source.
+func (e *events) verified(source string) []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	var ips []string
+	for _, event := range e.list {
+		if (event.Type == models.EventCandidateVerified || event.Type == models.EventCandidateRejected) && event.Source == source && event.Candidate != nil {
+			ips = append(ips, event.Candidate.IP.String())
+		}
+	}
+	return ips
+}
+
+func TestResumeWithChangedCandidateList(t *testing.T) {
+	const host = "resume.test"
+	serveZone(t, host)
+
+	origin := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<html><head><title>Acme Store</title></head></html>")
+	}))
+	defer origin.Close()
+	_, port, _ := net.SplitHostPort(origin.Listener.Addr().String())
+	var originPort int
+	fmt.Sscan(port, &originPort)
+
+	path := filepath.Join(t.TempDir(), "scan.checkpoint")
+	scan := func(list []models.Candidate) *events {
+		t.Helper()
+		progress, err := checkpoint.Open(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer progress.Close()
+		recorded := &events{}
+		s := New(&models.Options{
+			Worker:       1,
+			Threshold:    60,
+			Title:        "Acme Store",
+			HTTPMethod:   "GET",
+			MaxNeighbors: 256,
+		}, func(string) []string { return nil }, recorded.add)
+		s.Candidates = list
+		s.Checkpoint = progress
+		s.AddTargets([]string{"http://" + host})
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		s.Run(ctx)
+		return recorded
+	}
+
+	first := []models.Candidate{{IP: net.ParseIP("127.0.0.3"), Source: "list", Ports: []int{originPort}, PortsOpen: true}}
+	second := []models.Candidate{{IP: net.ParseIP("127.0.0.1"), Source: "list", Ports: []int{originPort}, PortsOpen: true}}
+
+	if got := scan(first).verified("Candidate list"); len(got) != 1 || got[0] != "127.0.0.3" {
+		t.Fatalf("first run verified %v, want [127.0.0.3]", got)
+	}
+
+	// Same length, other host: the list is verified again.
+	resumed := scan(second)
+	if got := resumed.verified("Candidate list"); len(got) != 1 || got[0] != "127.0.0.1" {
+		t.Fatalf("resumed run verified %v, want [127.0.0.1]", got)
+	}
+	found := false
+	for _, event := range resumed.list {
+		if event.Type == models.EventFinding && event.Finding.OriginIP == "127.0.0.1" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("resumed run did not report 127.0.0.1 as an origin")
+	}
+
+	// Unchanged list: the target is skipped.
+	if got := scan(second).verified("Candidate list"); len(got) != 0 {
+		t.Errorf("run with the completed list verified %v again", got)
+	}
+}
+
+func TestTargetDoneOnlyWhenComplete(t *testing.T) {
+	const host = "resume.test"
+	serveZone(t, host)
+
+	tests := []struct {
+		name   string
+		target string
+		title  string
+		want   bool
+	}{
+		{"complete", "http://" + host, "Acme Store", true},
+		// Without -title the baseline fetch fails, so the target is
+		// unverifiable.
+		{"no baseline", "http://" + host, "", false},
+		{"not resolved", "http://missing.test", "Acme Store", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			progress, err := checkpoint.Open(filepath.Join(t.TempDir(), "scan.checkpoint"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer progress.Close()
+			s := New(&models.Options{Worker: 1, Threshold: 60, Title: tt.title, HTTPMethod: "GET"}, func(string) []string { return nil }, nil)
+			s.Checkpoint = progress
+			s.AddTargets([]string{tt.target})
+			s.Run(context.Background())
+			if done := progress.TargetDone(tt.target) != ""; done != tt.want {
+				t.Errorf("TargetDone = %t, want %t", done, tt.want)
+			}
+		})
+	}
+}
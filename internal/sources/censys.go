@@ -0,0 +1,177 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"strings"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+func init() {
+	Register(func(cfg *Config) Source { return &censys{cfg: cfg} })
+}
+
+// censys searches the Censys Platform API for hosts presenting the domain.
+type censys struct {
+	cfg *Config
+}
+
+func (c *censys) ID() string             { return "censys" }
+func (c *censys) Name() string           { return "Censys" }
+func (c *censys) Description() string    { return "Include Censys in scanning" }
+func (c *censys) RequiredKeys() []string { return []string{"censys"} }
+
+func (c *censys) Discover(ctx context.Context, domain string) <-chan models.Candidate {
+	// CenQL query: match hosts that present the domain in their DNS names or in
+	// a served TLS certificate's leaf names (including subdomains).
+	query := fmt.Sprintf(`host.dns.names: "%s" or host.dns.names: "*.%s" or host.services.tls.certificates.leaf_data.names: "%s"`, domain, domain, domain)
+	return c.search(ctx, domain, query, c.Name(), 0)
+}
+
+func (c *censys) DiscoverFavicon(ctx context.Context, domain, hash string) <-chan models.Candidate {
+	return c.search(ctx, domain, fmt.Sprintf(`host.services.endpoints.http.favicons.hash_shodan: %s`, hash), c.Name()+" favicon", c.cfg.faviconPages())
+}
+
+func (c *censys) search(ctx context.Context, domain, query, source string, maxPages int) <-chan models.Candidate {
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		c.query(ctx, ch, domain, query, source, maxPages)
+	}()
+	return ch
+}
+
+// query runs a CenQL host query and streams every IP it returns as a
+// candidate from source, fetching at most maxPages pages when it is not
+// zero.
+func (c *censys) query(ctx context.Context, ch chan<- models.Candidate, domain, query, source string, maxPages int) {
+	// Censys Platform API. The config "censys" list holds the Personal Access
+	// Token (PAT) as the first entry and, optionally, the Organization ID as
+	// the second entry (required for paid tiers):
+	//   censys:
+	//     - "censys_pat_xxxxxxxx"
+	//     - "your-organization-id"   # optional
+	keys := c.cfg.keys(c.ID())
+	if len(keys) == 0 || keys[0] == "" {
+		return
+	}
+	pat := keys[0]
+	var orgID string
+	if len(keys) > 1 {
+		orgID = keys[1]
+	}
+
+	censysURL := "https://api.platform.censys.io/v3/global/search/query"
+	if orgID != "" {
+		censysURL += "?organization_id=" + neturl.QueryEscape(orgID)
+	}
+
+	client := c.cfg.client()
+	pageToken := ""
+
+	for page := 1; ; page++ {
+		requestBody := map[string]interface{}{
+			"query":     query,
+			"page_size": 100,
+		}
+		if pageToken != "" {
+			requestBody["page_token"] = pageToken
+		}
+		jsonBody, err := json.Marshal(requestBody)
+		if err != nil {
+			c.cfg.fail(ctx, models.LevelWarning, "[!] Error preparing Censys request body: %v", err)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", censysURL, strings.NewReader(string(jsonBody)))
+		if err != nil {
+			c.cfg.fail(ctx, models.LevelWarning, "[!] Error creating Censys request: %v", err)
+			return
+		}
+		req.Header.Set("Authorization", "Bearer "+pat)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", c.cfg.UserAgent)
+		if orgID != "" {
+			req.Header.Set("X-Organization-ID", orgID)
+		}
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if strings.Contains(err.Error(), "giving up after") {
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Censys API rate limit exceeded. Please try again later. (%s)", domain)
+			} else {
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Error making request to Censys API: %v", err)
+			}
+			return
+		}
+
+		// Check response status
+		if resp.StatusCode != 200 {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			var errorResponse struct {
+				Message string `json:"message"`
+				Detail  string `json:"detail"`
+			}
+			msg := strings.TrimSpace(string(bodyBytes))
+			if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
+				if errorResponse.Message != "" {
+					msg = errorResponse.Message
+				} else if errorResponse.Detail != "" {
+					msg = errorResponse.Detail
+				}
+			}
+			switch resp.StatusCode {
+			case 401:
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Censys API authentication failed (401). Check your Personal Access Token. %s", msg)
+			case 403:
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Censys API access denied (403). A paid plan and Organization ID may be required. %s", msg)
+			case 429:
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Censys API rate limit exceeded (429): %s", msg)
+			default:
+				c.cfg.fail(ctx, models.LevelWarning, "[!] Censys API returned non-200 status code %d: %s", resp.StatusCode, msg)
+			}
+			return
+		}
+
+		var data models.CensysPlatformResponse
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			resp.Body.Close()
+			c.cfg.fail(ctx, models.LevelWarning, "[!] Error decoding Censys response: %v", err)
+			return
+		}
+		resp.Body.Close()
+
+		for _, hit := range data.Result.Hits {
+			if hit.HostV1 == nil {
+				continue
+			}
+			ip := net.ParseIP(hit.HostV1.Resource.IP)
+			if ip == nil {
+				continue
+			}
+			if !send(ctx, ch, models.Candidate{IP: ip, Source: source}) {
+				return
+			}
+		}
+
+		if data.Result.NextPageToken == "" {
+			break
+		}
+		if page == maxPages {
+			c.cfg.truncated(source, domain, page, data.Result.TotalHits)
+			break
+		}
+		pageToken = data.Result.NextPageToken
+	}
+}
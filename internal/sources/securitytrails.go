@@ -0,0 +1,139 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+func init() {
+	Register(func(cfg *Config) Source { return &securityTrails{cfg: cfg} })
+}
+
+// securityTrails reads the domain's historical A and AAAA records.
+type securityTrails struct {
+	cfg *Config
+}
+
+func (s *securityTrails) ID() string   { return "securitytrails" }
+func (s *securityTrails) Name() string { return "SecurityTrails" }
+func (s *securityTrails) Description() string {
+	return "Include SecurityTrails historical DNS records in scanning"
+}
+func (s *securityTrails) RequiredKeys() []string { return []string{"securitytrails"} }
+
+func (s *securityTrails) Discover(ctx context.Context, domain string) <-chan models.Candidate {
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		key := s.cfg.key(s.ID())
+		if key == "" {
+			return
+		}
+		client := s.cfg.client()
+
+		// IPv4 history first; a failure there (bad key, quota) would only
+		// repeat for the IPv6 history.
+		for _, recordType := range []string{"a", "aaaa"} {
+			data, ok := s.history(ctx, client, key, domain, recordType)
+			if !ok {
+				return
+			}
+
+			for _, record := range data.Records {
+				org := "Unknown"
+				if len(record.Organizations) > 0 {
+					org = strings.Join(record.Organizations, ", ")
+				}
+				period := fmt.Sprintf("%s to %s", record.FirstSeen, record.LastSeen)
+
+				for _, value := range record.Values {
+					// AAAA history carries the address in "ipv6".
+					address := value.IP
+					if address == "" {
+						address = value.IPv6
+					}
+					ip := net.ParseIP(address)
+					if ip == nil {
+						continue
+					}
+					candidate := models.Candidate{
+						IP:     ip,
+						Source: s.Name(),
+						Detail: fmt.Sprintf("Organization: %s - Period: %s", org, period),
+					}
+					if !send(ctx, ch, candidate) {
+						return
+					}
+				}
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *securityTrails) history(ctx context.Context, client *http.Client, key, domain, recordType string) (data models.SecurityTrailsResponse, ok bool) {
+	apiURL := fmt.Sprintf("https://api.securitytrails.com/v1/history/%s/dns/%s", domain, recordType)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+	if err != nil {
+		s.cfg.fail(ctx, models.LevelWarning, "[!] Error creating SecurityTrails request: %v", err)
+		return data, false
+	}
+	req.Header.Set("APIKEY", key)
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", s.cfg.UserAgent)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return data, false
+		}
+		if strings.Contains(err.Error(), "giving up after") {
+			s.cfg.fail(ctx, models.LevelWarning, "[!] SecurityTrails API rate limit exceeded. Please try again later. (%s)", domain)
+		} else {
+			s.cfg.fail(ctx, models.LevelWarning, "[!] Error making request to SecurityTrails API: %v", err)
+		}
+		return data, false
+	}
+	defer resp.Body.Close()
+
+	// Check response status
+	if resp.StatusCode != 200 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		var errorResponse struct {
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil {
+			if strings.Contains(errorResponse.Message, "exceeded the usage limits") {
+				s.cfg.fail(ctx, models.LevelWarning, "[!] SecurityTrails API rate limit exceeded: %s", errorResponse.Message)
+			} else {
+				s.cfg.fail(ctx, models.LevelWarning, "[!] SecurityTrails API error: %s", errorResponse.Message)
+			}
+		} else {
+			s.cfg.fail(ctx, models.LevelWarning, "[!] SecurityTrails API returned non-200 status code %d: %s", resp.StatusCode, string(bodyBytes))
+		}
+		// The failure is reported once above; the response details only
+		// help diagnose it.
+		s.cfg.logf(models.LevelWarning, "[!] HTTP Status: %s", resp.Status)
+		s.cfg.logf(models.LevelWarning, "[!] HTTP Headers:")
+		for key, values := range resp.Header {
+			for _, value := range values {
+				s.cfg.logf(models.LevelWarning, "[!]   %s: %s", key, value)
+			}
+		}
+		return data, false
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		s.cfg.fail(ctx, models.LevelWarning, "[!] Error decoding SecurityTrails response: %v", err)
+		return data, false
+	}
+	return data, true
+}
@@ -0,0 +1,196 @@
+package sources
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	neturl "net/url"
+	"time"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+func init() {
+	Register(func(cfg *Config) Source { return &shodan{cfg: cfg} })
+}
+
+// shodan reads the domain's historical DNS records and searches hosts by
+// favicon hash.
+type shodan struct {
+	cfg *Config
+}
+
+func (s *shodan) ID() string   { return "shodan" }
+func (s *shodan) Name() string { return "Shodan" }
+func (s *shodan) Description() string {
+	return "Include Shodan historical DNS records in scanning"
+}
+func (s *shodan) RequiredKeys() []string { return []string{"shodan"} }
+
+func (s *shodan) Discover(ctx context.Context, domain string) <-chan models.Candidate {
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		key := s.cfg.key(s.ID())
+		if key == "" {
+			return
+		}
+
+		apiURL := fmt.Sprintf("https://api.shodan.io/dns/domain/%s?key=%s&history=true", domain, key)
+		resp, ok := s.get(ctx, apiURL)
+		if !ok {
+			return
+		}
+		defer resp.Body.Close()
+
+		var data models.ShodanDNSHistoryResponse
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			s.cfg.fail(ctx, models.LevelWarning, "[!] Error decoding Shodan response: %v", err)
+			return
+		}
+
+		for _, record := range data.Data {
+			if record.Type != "A" && record.Type != "AAAA" {
+				continue
+			}
+			ip := net.ParseIP(record.Value)
+			if ip == nil {
+				continue
+			}
+			candidate := models.Candidate{
+				IP:     ip,
+				Source: s.Name(),
+				Ports:  record.Ports,
+				Detail: "Last seen: " + record.LastSeen,
+			}
+			if !send(ctx, ch, candidate) {
+				return
+			}
+		}
+	}()
+	return ch
+}
+
+func (s *shodan) DiscoverFavicon(ctx context.Context, domain, hash string) <-chan models.Candidate {
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		key := s.cfg.key(s.ID())
+		if key == "" {
+			return
+		}
+
+		query := neturl.QueryEscape("http.favicon.hash:" + hash)
+		seen := make(map[string]bool)
+
+		// Each page of results costs one Shodan query credit, so a common
+		// favicon is only searched up to the page limit.
+		maxPages := s.cfg.faviconPages()
+		for page := 1; ; page++ {
+			apiURL := fmt.Sprintf("https://api.shodan.io/shodan/host/search?key=%s&query=%s&page=%d", key, query, page)
+			resp, ok := s.get(ctx, apiURL)
+			if !ok {
+				return
+			}
+
+			var data models.ShodanHostSearchResponse
+			if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+				resp.Body.Close()
+				s.cfg.fail(ctx, models.LevelWarning, "[!] Error decoding Shodan response: %v", err)
+				return
+			}
+			resp.Body.Close()
+
+			for _, match := range data.Matches {
+				ip := net.ParseIP(match.IPStr)
+				if ip == nil || seen[match.IPStr] {
+					continue
+				}
+				seen[match.IPStr] = true
+				candidate := models.Candidate{
+					IP:     ip,
+					Source: s.Name() + " favicon",
+					Ports:  []int{match.Port},
+					Detail: fmt.Sprintf("Port: %d", match.Port),
+				}
+				if !send(ctx, ch, candidate) {
+					return
+				}
+			}
+
+			if len(data.Matches) < 100 || page*100 >= data.Total {
+				break
+			}
+			if page == maxPages {
+				s.cfg.truncated(s.Name()+" favicon", domain, page, data.Total)
+				break
+			}
+		}
+	}()
+	return ch
+}
+
+// get requests apiURL, retrying with exponential backoff, and returns the
+// response when it is a 200. Any other outcome is reported and ok is false.
+func (s *shodan) get(ctx context.Context, apiURL string) (resp *http.Response, ok bool) {
+	const maxRetries = 3
+	client := s.cfg.client()
+
+	for retryCount := 1; ; retryCount++ {
+		req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
+		if err != nil {
+			s.cfg.fail(ctx, models.LevelWarning, "[!] Error creating Shodan request: %v", err)
+			return nil, false
+		}
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("User-Agent", s.cfg.UserAgent)
+
+		resp, err = client.Do(req)
+		if err == nil && resp.StatusCode == 200 {
+			return resp, true
+		}
+		if ctx.Err() != nil {
+			if resp != nil {
+				resp.Body.Close()
+			}
+			return nil, false
+		}
+
+		if retryCount == maxRetries {
+			// Retries exhausted. A transport error has no usable response, so
+			// report it and bail. A non-200 response is read so the real
+			// reason (e.g. plan/credits) is surfaced.
+			if err != nil {
+				s.cfg.fail(ctx, models.LevelError, "[-] Error making request to Shodan API after %d retries: %v", maxRetries, err)
+				return nil, false
+			}
+			defer resp.Body.Close()
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			var errorResponse struct {
+				Error string `json:"error"`
+			}
+			if err := json.Unmarshal(bodyBytes, &errorResponse); err == nil && errorResponse.Error != "" {
+				s.cfg.fail(ctx, models.LevelWarning, "[!] Shodan API error (%d): %s", resp.StatusCode, errorResponse.Error)
+			} else {
+				s.cfg.fail(ctx, models.LevelWarning, "[!] Shodan API returned non-200 status code %d: %s", resp.StatusCode, string(bodyBytes))
+			}
+			return nil, false
+		}
+
+		if resp != nil {
+			resp.Body.Close()
+		}
+
+		// Exponential backoff: 1s, 2s, 4s, ...
+		waitTime := time.Duration(1<<uint(retryCount-1)) * time.Second
+		s.cfg.logf(models.LevelWarning, "[!] Retrying Shodan API request in %v (attempt %d/%d)...", waitTime, retryCount, maxRetries)
+		select {
+		case <-time.After(waitTime):
+		case <-ctx.Done():
+			return nil, false
+		}
+	}
+}
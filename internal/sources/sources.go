@@ -0,0 +1,147 @@
+// Package sources implements passive origin discovery providers. Each
+// provider is a Source registered from its own file; flags, API key checks and
+// the scan banner are all derived from the registry.
+package sources
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+
+	httpClient "github.com/musana/cf-hero/internal/http"
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+// Source discovers candidate origin IPs for a domain.
+type Source interface {
+	// ID is the source's flag name and its key in cf-hero.yaml.
+	ID() string
+	// Name is the display name used in output and as the finding source.
+	Name() string
+	// Description is the flag usage text.
+	Description() string
+	// RequiredKeys lists the cf-hero.yaml entries that must be set for the
+	// source to run.
+	RequiredKeys() []string
+	// Discover streams candidates for domain. The channel is closed when the
+	// source is done or ctx is cancelled.
+	Discover(ctx context.Context, domain string) <-chan models.Candidate
+}
+
+// FaviconSource is implemented by sources that can search for hosts serving
+// a favicon with the given Shodan-style hash.
+type FaviconSource interface {
+	Source
+	DiscoverFavicon(ctx context.Context, domain, hash string) <-chan models.Candidate
+}
+
+// DefaultFaviconPages is the number of result pages a favicon search fetches
+// from each source when Config.FaviconPages is not set.
+const DefaultFaviconPages = 5
+
+// Config holds the settings shared by every source.
+type Config struct {
+	Proxy     string
+	UserAgent string
+	// FaviconPages caps the result pages of a favicon search, each of which
+	// may cost an API credit. Zero means DefaultFaviconPages.
+	FaviconPages int
+	// APIKeys returns the configured keys for a cf-hero.yaml entry.
+	APIKeys func(id string) []string
+	// Logf, when set, receives the errors sources run into.
+	Logf func(level models.Level, format string, args ...interface{})
+}
+
+var registry []func(*Config) Source
+
+// Register adds a source constructor to the registry. Sources call it from
+// an init function.
+func Register(constructor func(*Config) Source) {
+	registry = append(registry, constructor)
+}
+
+// All returns an instance of every registered source, in registration order.
+func All(cfg *Config) []Source {
+	sources := make([]Source, 0, len(registry))
+	for _, constructor := range registry {
+		sources = append(sources, constructor(cfg))
+	}
+	return sources
+}
+
+// HasKeys reports whether every key the source requires is configured.
+func HasKeys(source Source, cfg *Config) bool {
+	for _, id := range source.RequiredKeys() {
+		if keys := cfg.keys(id); len(keys) == 0 || keys[0] == "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (cfg *Config) keys(id string) []string {
+	if cfg == nil || cfg.APIKeys == nil {
+		return nil
+	}
+	return cfg.APIKeys(id)
+}
+
+// key returns the first configured key of a cf-hero.yaml entry.
+func (cfg *Config) key(id string) string {
+	if keys := cfg.keys(id); len(keys) > 0 {
+		return keys[0]
+	}
+	return ""
+}
+
+func (cfg *Config) logf(level models.Level, format string, args ...interface{}) {
+	if cfg != nil && cfg.Logf != nil {
+		cfg.Logf(level, format, args...)
+	}
+}
+
+func (cfg *Config) faviconPages() int {
+	if cfg == nil || cfg.FaviconPages <= 0 {
+		return DefaultFaviconPages
+	}
+	return cfg.FaviconPages
+}
+
+// truncated reports that a search stopped at its page limit before every
+// result was fetched.
+func (cfg *Config) truncated(source, domain string, pages, total int) {
+	cfg.logf(models.LevelWarning, "[!] %s search for %s stopped after %d pages of %d results. Raise -favicon-pages to fetch the rest.", source, domain, pages, total)
+}
+
+type failuresKey struct{}
+
+// WithFailures returns a context that records whether a source run using it
+// ran into an error, such as a failed or rejected API call, and a function
+// reporting whether one did. A run without errors returned everything the
+// source had.
+func WithFailures(ctx context.Context) (context.Context, func() bool) {
+	failed := new(atomic.Bool)
+	return context.WithValue(ctx, failuresKey{}, failed), failed.Load
+}
+
+// fail reports an error that cut a source run short.
+func (cfg *Config) fail(ctx context.Context, level models.Level, format string, args ...interface{}) {
+	if failed, ok := ctx.Value(failuresKey{}).(*atomic.Bool); ok {
+		failed.Store(true)
+	}
+	cfg.logf(level, format, args...)
+}
+
+func (cfg *Config) client() *http.Client {
+	return httpClient.NewHTTPClient(cfg.Proxy, "")
+}
+
+// send delivers a candidate unless ctx is cancelled first.
+func send(ctx context.Context, ch chan<- models.Candidate, candidate models.Candidate) bool {
+	select {
+	case ch <- candidate:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,165 @@
+package sources
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+func init() {
+	Register(func(cfg *Config) Source { return &zoomeye{cfg: cfg} })
+}
+
+// zoomeye searches ZoomEye for hosts serving the domain.
+type zoomeye struct {
+	cfg *Config
+}
+
+func (z *zoomeye) ID() string             { return "zoomeye" }
+func (z *zoomeye) Name() string           { return "ZoomEye" }
+func (z *zoomeye) Description() string    { return "Include Zoomeye in scanning" }
+func (z *zoomeye) RequiredKeys() []string { return []string{"zoomeye"} }
+
+func (z *zoomeye) Discover(ctx context.Context, domain string) <-chan models.Candidate {
+	// The domain value must be quoted per ZoomEye's dork syntax, otherwise
+	// values containing dots may be parsed incorrectly.
+	return z.search(ctx, domain, fmt.Sprintf(`domain="%s"`, domain), z.Name(), 0)
+}
+
+func (z *zoomeye) DiscoverFavicon(ctx context.Context, domain, hash string) <-chan models.Candidate {
+	return z.search(ctx, domain, fmt.Sprintf(`iconhash="%s"`, hash), z.Name()+" favicon", z.cfg.faviconPages())
+}
+
+func (z *zoomeye) search(ctx context.Context, domain, query, source string, maxPages int) <-chan models.Candidate {
+	ch := make(chan models.Candidate)
+	go func() {
+		defer close(ch)
+		z.query(ctx, ch, domain, query, source, maxPages)
+	}()
+	return ch
+}
+
+// query runs a ZoomEye dork and streams every IP it returns as a candidate
+// from source, fetching at most maxPages pages when it is not zero.
+func (z *zoomeye) query(ctx context.Context, ch chan<- models.Candidate, domain, query, source string, maxPages int) {
+	key := z.cfg.key(z.ID())
+	if key == "" {
+		return
+	}
+
+	// Base64 encode the query.
+	queryBase64 := base64.StdEncoding.EncodeToString([]byte(query))
+
+	client := z.cfg.client()
+	resultsPerPage := 100
+
+	for page := 1; ; page++ {
+		// Prepare request body
+		requestBody := map[string]interface{}{
+			"qbase64":  queryBase64,
+			"page":     page,
+			"pagesize": resultsPerPage,
+		}
+		jsonBody, err := json.Marshal(requestBody)
+		if err != nil {
+			z.cfg.fail(ctx, models.LevelError, "[-] Error preparing ZoomEye request body: %v", err)
+			return
+		}
+
+		req, err := http.NewRequestWithContext(ctx, "POST", "https://api.zoomeye.ai/v2/search", strings.NewReader(string(jsonBody)))
+		if err != nil {
+			z.cfg.fail(ctx, models.LevelError, "[-] Error creating ZoomEye request: %v", err)
+			return
+		}
+		req.Header.Set("API-KEY", key)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("User-Agent", z.cfg.UserAgent)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			if ctx.Err() == nil {
+				z.cfg.fail(ctx, models.LevelError, "[-] Error making request to ZoomEye API: %v", err)
+			}
+			return
+		}
+
+		// Check response status
+		if resp.StatusCode != 200 {
+			bodyBytes, _ := io.ReadAll(resp.Body)
+			resp.Body.Close()
+			z.cfg.fail(ctx, models.LevelWarning, "[!] ZoomEye API returned non-200 status code %d: %s", resp.StatusCode, string(bodyBytes))
+			return
+		}
+
+		var data models.ZoomeyeResponse
+		if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+			resp.Body.Close()
+			z.cfg.fail(ctx, models.LevelError, "[-] Error decoding ZoomEye response: %v", err)
+			return
+		}
+		resp.Body.Close()
+
+		// ZoomEye signals API-level errors (quota, auth, bad query) with a
+		// non-60000 code even on an HTTP 200 response.
+		if data.Code != 60000 {
+			z.cfg.fail(ctx, models.LevelWarning, "[!] ZoomEye API error (code %d): %s", data.Code, data.Message)
+			return
+		}
+
+		for _, result := range data.Data {
+			ip := net.ParseIP(result.IP)
+			if ip == nil {
+				continue
+			}
+
+			port, err := parsePort(result.Port)
+			if err != nil {
+				z.cfg.fail(ctx, models.LevelError, "[-] Error converting port %s to int: %v", string(result.Port), err)
+				continue
+			}
+
+			candidate := models.Candidate{
+				IP:     ip,
+				Source: source,
+				Ports:  []int{port},
+				Detail: fmt.Sprintf("Port: %d, Domain: %s, Updated: %s", port, result.Domain, result.UpdateTime),
+			}
+			if !send(ctx, ch, candidate) {
+				return
+			}
+		}
+
+		// Stop when the API returns a short/empty page or we've covered every
+		// reported result. Guarding on the returned page length avoids an
+		// infinite loop if the reported total is inaccurate.
+		if len(data.Data) < resultsPerPage || page*resultsPerPage >= data.Total {
+			break
+		}
+		if page == maxPages {
+			z.cfg.truncated(source, domain, page, data.Total)
+			break
+		}
+	}
+}
+
+// parsePort reads a ZoomEye port, which is sent either as a number or as a
+// string.
+func parsePort(raw json.RawMessage) (int, error) {
+	var port int
+	if err := json.Unmarshal(raw, &port); err == nil {
+		return port, nil
+	}
+	var portStr string
+	if err := json.Unmarshal(raw, &portStr); err != nil {
+		return 0, err
+	}
+	return strconv.Atoi(portStr)
+}
@@ -0,0 +1,98 @@
+package target
+
+import (
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strconv"
+	"strings"
+)
+
+// Target is a site to scan, parsed from an input line such as
+// "https://example.com:8443/login", "example.com/login" or "example.com".
+type Target struct {
+	// Input is the line the target was parsed from.
+	Input string
+	// Scheme is "http" or "https", or empty when the input named none, in
+	// which case both are tried.
+	Scheme string
+	// Host is the lowercase hostname, without port or trailing dot. It is
+	// the only part used for DNS lookups.
+	Host string
+	// Port is the explicit port of the input, or empty.
+	Port string
+	// Path is the path and query the baseline is fetched from, "/" by
+	// default.
+	Path string
+}
+
+// Parse parses an input line into a Target. Bare hostnames and IP addresses
+// are accepted, IPv6 ones with or without brackets; schemes other than http
+// and https are not.
+func Parse(input string) (Target, error) {
+	raw := strings.TrimSpace(input)
+	if raw == "" {
+		return Target{}, fmt.Errorf("empty target")
+	}
+	if net.ParseIP(raw) != nil && strings.Contains(raw, ":") {
+		// A bare IPv6 address would otherwise have its last group read as
+		// a port.
+		raw = "[" + raw + "]"
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "//" + raw
+	}
+	u, err := neturl.Parse(raw)
+	if err != nil {
+		return Target{}, err
+	}
+
+	scheme := strings.ToLower(u.Scheme)
+	if scheme != "" && scheme != "http" && scheme != "https" {
+		return Target{}, fmt.Errorf("unsupported scheme %q", u.Scheme)
+	}
+	host := strings.TrimSuffix(strings.ToLower(u.Hostname()), ".")
+	if host == "" {
+		return Target{}, fmt.Errorf("missing host")
+	}
+	port := u.Port()
+	if port != "" {
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return Target{}, fmt.Errorf("invalid port %q", port)
+		}
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return Target{Input: strings.TrimSpace(input), Scheme: scheme, Host: host, Port: port, Path: path}, nil
+}
+
+// URL returns the target's URL for the given scheme.
+func (t Target) URL(scheme string) string {
+	host := t.Host
+	if t.Port != "" {
+		host = net.JoinHostPort(t.Host, t.Port)
+	} else if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return scheme + "://" + host + t.Path
+}
+
+// URLs returns the URLs the target may be reached at: the one of its scheme,
+// or the HTTPS and then the HTTP one when it has none.
+func (t Target) URLs() []string {
+	if t.Scheme != "" {
+		return []string{t.URL(t.Scheme)}
+	}
+	return []string{t.URL("https"), t.URL("http")}
+}
+
+// String returns the target as it was given.
+func (t Target) String() string {
+	return t.Input
+}
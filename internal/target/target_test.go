@@ -0,0 +1,118 @@
+package target
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		input string
+		want  Target
+		urls  []string
+	}{
+		{
+			input: "example.com",
+			want:  Target{Host: "example.com", Path: "/"},
+			urls:  []string{"https://example.com/", "http://example.com/"},
+		},
+		{
+			input: "  Example.COM.  ",
+			want:  Target{Host: "example.com", Path: "/"},
+			urls:  []string{"https://example.com/", "http://example.com/"},
+		},
+		{
+			input: "https://example.com",
+			want:  Target{Scheme: "https", Host: "example.com", Path: "/"},
+			urls:  []string{"https://example.com/"},
+		},
+		{
+			input: "HTTP://example.com/login?next=/home",
+			want:  Target{Scheme: "http", Host: "example.com", Path: "/login?next=/home"},
+			urls:  []string{"http://example.com/login?next=/home"},
+		},
+		{
+			input: "https://example.com:8443/admin",
+			want:  Target{Scheme: "https", Host: "example.com", Port: "8443", Path: "/admin"},
+			urls:  []string{"https://example.com:8443/admin"},
+		},
+		{
+			input: "example.com:8080",
+			want:  Target{Host: "example.com", Port: "8080", Path: "/"},
+			urls:  []string{"https://example.com:8080/", "http://example.com:8080/"},
+		},
+		{
+			input: "192.0.2.10:8443",
+			want:  Target{Host: "192.0.2.10", Port: "8443", Path: "/"},
+			urls:  []string{"https://192.0.2.10:8443/", "http://192.0.2.10:8443/"},
+		},
+		{
+			input: "2001:db8::1",
+			want:  Target{Host: "2001:db8::1", Path: "/"},
+			urls:  []string{"https://[2001:db8::1]/", "http://[2001:db8::1]/"},
+		},
+		{
+			input: "::1",
+			want:  Target{Host: "::1", Path: "/"},
+			urls:  []string{"https://[::1]/", "http://[::1]/"},
+		},
+		{
+			input: "[2001:db8::1]",
+			want:  Target{Host: "2001:db8::1", Path: "/"},
+			urls:  []string{"https://[2001:db8::1]/", "http://[2001:db8::1]/"},
+		},
+		{
+			input: "[2001:db8::1]:8443",
+			want:  Target{Host: "2001:db8::1", Port: "8443", Path: "/"},
+			urls:  []string{"https://[2001:db8::1]:8443/", "http://[2001:db8::1]:8443/"},
+		},
+		{
+			input: "https://[2001:DB8::1]/status",
+			want:  Target{Scheme: "https", Host: "2001:db8::1", Path: "/status"},
+			urls:  []string{"https://[2001:db8::1]/status"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error: %v", tt.input, err)
+			}
+			tt.want.Input = strings.TrimSpace(tt.input)
+			if got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+			if urls := got.URLs(); !reflect.DeepEqual(urls, tt.urls) {
+				t.Errorf("URLs() = %v, want %v", urls, tt.urls)
+			}
+		})
+	}
+}
+
+func TestParseInput(t *testing.T) {
+	got, err := Parse("  https://example.com/a \n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Input != "https://example.com/a" || got.String() != "https://example.com/a" {
+		t.Errorf("Input = %q, String() = %q, want the trimmed line", got.Input, got.String())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"   ",
+		"ftp://example.com",
+		"https://",
+		"example.com:0",
+		"example.com:65536",
+		"example.com:http",
+		"https://[2001:db8::1]:99999/",
+	} {
+		if got, err := Parse(input); err == nil {
+			t.Errorf("Parse(%q) = %+v, want an error", input, got)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package verify
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"strings"
+)
+
+// SignalCertificate is reported when a candidate's TLS certificate ties it to
+// the target.
+const SignalCertificate = "certificate"
+
+// Certificate scores. A certificate is strong evidence on its own, so a match
+// is scored directly instead of being weighed against the page signals.
+const (
+	// certScoreSPKI is used when the candidate presents the same key as the
+	// certificate Cloudflare serves, i.e. a custom certificate shared between
+	// the edge and the origin.
+	certScoreSPKI = 95
+	// certScoreName is used when the candidate's certificate names the domain.
+	certScoreName = 85
+	// certScoreRelated is used when the certificate only covers the domain
+	// through a wildcard or as its www counterpart. Any host of the company,
+	// such as a mail server, may carry such a certificate, so the match stays
+	// below DefaultThreshold and the page signals have to confirm the
+	// candidate on their own.
+	certScoreRelated = 40
+)
+
+// Ways a certificate's names can cover a domain.
+const (
+	nameNone = iota
+	// nameRelated is a wildcard or www/apex match.
+	nameRelated
+	nameExact
+)
+
+// SPKIFingerprint returns the hex SHA-256 of the certificate's public key.
+func SPKIFingerprint(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return hex.EncodeToString(sum[:])
+}
+
+// AddCertificate records the certificate Cloudflare serves for the target so
+// candidates presenting the same key can be recognized.
+func (b *Baseline) AddCertificate(cert *x509.Certificate) {
+	if cert == nil {
+		return
+	}
+	fingerprint := SPKIFingerprint(cert)
+	for _, known := range b.SPKI {
+		if known == fingerprint {
+			return
+		}
+	}
+	b.SPKI = append(b.SPKI, fingerprint)
+}
+
+// ScoreCertificates scores the certificates a candidate presented against the
+// baseline. The best match among them wins.
+func ScoreCertificates(b *Baseline, certs []*x509.Certificate) Result {
+	var result Result
+	if b == nil {
+		return result
+	}
+	for _, cert := range certs {
+		score := 0
+		for _, known := range b.SPKI {
+			if SPKIFingerprint(cert) == known {
+				score = certScoreSPKI
+				break
+			}
+		}
+		if score == 0 && b.Domain != "" {
+			switch certificateNames(cert, b.Domain) {
+			case nameExact:
+				score = certScoreName
+			case nameRelated:
+				score = certScoreRelated
+			}
+		}
+		if score > result.Score {
+			result.Score = score
+			result.Signals = []string{SignalCertificate}
+		}
+	}
+	return result
+}
+
+// Merge combines two results, keeping the higher score and every signal that
+// matched in either of them.
+func Merge(a, b Result) Result {
+	merged := Result{Score: a.Score, Signals: append([]string{}, a.Signals...)}
+	if b.Score > merged.Score {
+		merged.Score = b.Score
+	}
+	for _, signal := range b.Signals {
+		found := false
+		for _, existing := range merged.Signals {
+			if existing == signal {
+				found = true
+				break
+			}
+		}
+		if !found {
+			merged.Signals = append(merged.Signals, signal)
+		}
+	}
+	return merged
+}
+
+// certificateNames reports how the certificate's SANs or subject CN cover
+// domain: exactly, through a wildcard or as its www counterpart (related), or
+// not at all. The closest match wins.
+func certificateNames(cert *x509.Certificate, domain string) int {
+	domain = strings.ToLower(strings.TrimSuffix(domain, "."))
+	names := append([]string{cert.Subject.CommonName}, cert.DNSNames...)
+	match := nameNone
+	for _, name := range names {
+		name = strings.ToLower(strings.TrimSuffix(name, "."))
+		if name == "" {
+			continue
+		}
+		if name == domain {
+			return nameExact
+		}
+		if name == "www."+domain || "www."+name == domain {
+			match = nameRelated
+		}
+		if strings.HasPrefix(name, "*.") {
+			suffix := name[1:]
+			if strings.HasSuffix(domain, suffix) && !strings.Contains(strings.TrimSuffix(domain, suffix), ".") {
+				match = nameRelated
+			}
+		}
+	}
+	return match
+}
@@ -0,0 +1,60 @@
+package verify
+
+import (
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+)
+
+func newCert(key, cn string, names ...string) *x509.Certificate {
+	return &x509.Certificate{
+		Subject:                 pkix.Name{CommonName: cn},
+		DNSNames:                names,
+		RawSubjectPublicKeyInfo: []byte(key),
+	}
+}
+
+func TestScoreCertificates(t *testing.T) {
+	edge := newCert("edge-key", "shop.example.com", "shop.example.com")
+	baseline := &Baseline{Domain: "shop.example.com"}
+	baseline.AddCertificate(edge)
+
+	tests := []struct {
+		name  string
+		certs []*x509.Certificate
+		want  int
+	}{
+		{"same key as the edge", []*x509.Certificate{newCert("edge-key", "other.example.net")}, certScoreSPKI},
+		{"exact SAN", []*x509.Certificate{newCert("origin-key", "", "api.example.com", "shop.example.com")}, certScoreName},
+		{"exact CN", []*x509.Certificate{newCert("origin-key", "SHOP.example.com.")}, certScoreName},
+		{"wildcard SAN", []*x509.Certificate{newCert("mail-key", "", "*.example.com")}, certScoreRelated},
+		{"www counterpart", []*x509.Certificate{newCert("origin-key", "", "www.shop.example.com")}, certScoreRelated},
+		{"parent domain", []*x509.Certificate{newCert("origin-key", "", "example.com")}, 0},
+		{"wildcard one level too deep", []*x509.Certificate{newCert("origin-key", "", "*.com")}, 0},
+		{"unrelated", []*x509.Certificate{newCert("origin-key", "localhost", "localhost")}, 0},
+		{"best certificate wins", []*x509.Certificate{
+			newCert("mail-key", "", "*.example.com"),
+			newCert("origin-key", "", "shop.example.com"),
+		}, certScoreName},
+		{"exact name among wildcards", []*x509.Certificate{newCert("origin-key", "", "*.example.com", "shop.example.com")}, certScoreName},
+		{"no certificate", nil, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ScoreCertificates(baseline, tt.certs).Score; got != tt.want {
+				t.Errorf("Score = %d, want %d", got, tt.want)
+			}
+		})
+	}
+
+	if certScoreRelated >= DefaultThreshold {
+		t.Errorf("a wildcard or www/apex match alone (%d) reaches the default threshold (%d)", certScoreRelated, DefaultThreshold)
+	}
+}
+
+func TestScoreCertificatesApex(t *testing.T) {
+	baseline := &Baseline{Domain: "www.example.com"}
+	if got := ScoreCertificates(baseline, []*x509.Certificate{newCert("origin-key", "", "example.com")}).Score; got != certScoreRelated {
+		t.Errorf("Score = %d, want %d", got, certScoreRelated)
+	}
+}
@@ -0,0 +1,89 @@
+package verify
+
+import (
+	"bytes"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+
+	"golang.org/x/net/html"
+)
+
+// Simhash returns a 64-bit locality-sensitive hash of an HTML body. Pages that
+// differ only in a few tokens (timestamps, nonces, CSRF tokens) produce hashes
+// with a small Hamming distance.
+func Simhash(body []byte) uint64 {
+	tokens := pageTokens(body)
+	if len(tokens) == 0 {
+		return 0
+	}
+
+	var weights [64]int
+	for i := range tokens {
+		end := i + 3
+		if end > len(tokens) {
+			end = len(tokens)
+		}
+		h := fnv.New64a()
+		h.Write([]byte(strings.Join(tokens[i:end], " ")))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<uint(bit)) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var hash uint64
+	for bit, w := range weights {
+		if w > 0 {
+			hash |= 1 << uint(bit)
+		}
+	}
+	return hash
+}
+
+// HammingDistance returns the number of differing bits between two hashes.
+func HammingDistance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// pageTokens extracts the visible words and link targets of an HTML document,
+// lowercased. Script and style contents are skipped.
+func pageTokens(body []byte) []string {
+	var tokens []string
+	skip := false
+	z := html.NewTokenizer(bytes.NewReader(body))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return tokens
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			tag := string(name)
+			skip = tag == "script" || tag == "style"
+			for hasAttr {
+				var key, val []byte
+				key, val, hasAttr = z.TagAttr()
+				if k := string(key); k == "href" || k == "src" || k == "action" {
+					tokens = append(tokens, strings.ToLower(string(val)))
+				}
+			}
+		case html.EndTagToken:
+			skip = false
+		case html.TextToken:
+			if skip {
+				continue
+			}
+			words := strings.FieldsFunc(string(z.Text()), func(r rune) bool {
+				return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+			})
+			for _, w := range words {
+				tokens = append(tokens, strings.ToLower(w))
+			}
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package verify
+
+import (
+	"net/http"
+	neturl "net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	httpClient "github.com/musana/cf-hero/internal/http"
+)
+
+// Signal names reported in Result.Signals.
+const (
+	SignalTitle    = "title"
+	SignalStatus   = "status"
+	SignalBody     = "body"
+	SignalHeaders  = "headers"
+	SignalCookies  = "cookies"
+	SignalRedirect = "redirect"
+	SignalFavicon  = "favicon"
+)
+
+// Signal weights. A generic title ("Home", "Login") says little about the
+// site, so it only carries a fraction of the normal title weight.
+const (
+	weightTitle        = 35
+	weightGenericTitle = 10
+	weightStatus       = 10
+	weightBody         = 30
+	weightHeaders      = 15
+	weightCookies      = 10
+	weightRedirect     = 30
+	weightFavicon      = 20
+)
+
+// maxBodyDistance is the largest simhash Hamming distance at which two bodies
+// still count as the same page.
+const maxBodyDistance = 10
+
+// DefaultThreshold is the minimum score for a candidate to be reported.
+const DefaultThreshold = 60
+
+var (
+	volatileToken = regexp.MustCompile(`[0-9a-f]{16,}|[A-Za-z0-9_\-]{24,}|\d+`)
+	whitespace    = regexp.MustCompile(`\s+`)
+
+	genericTitles = map[string]bool{
+		"home": true, "index": true, "login": true, "log in": true, "sign in": true,
+		"welcome": true, "dashboard": true, "default": true, "untitled": true,
+		"document": true, "loading...": true, "redirecting...": true,
+		"403 forbidden": true, "404 not found": true, "just a moment...": true,
+	}
+
+	// commonHeaders are sent by almost every server or added by Cloudflare
+	// itself and therefore do not identify an application.
+	commonHeaders = map[string]bool{
+		"Date": true, "Content-Length": true, "Content-Type": true, "Connection": true,
+		"Keep-Alive": true, "Transfer-Encoding": true, "Cache-Control": true,
+		"Expires": true, "Pragma": true, "Last-Modified": true, "Etag": true,
+		"Vary": true, "Accept-Ranges": true, "Age": true, "Server": true,
+		"Set-Cookie": true, "Location": true, "Alt-Svc": true, "Nel": true,
+		"Report-To": true, "Server-Timing": true, "Speculation-Rules": true,
+		"Content-Encoding": true, "Strict-Transport-Security": true,
+	}
+
+	cloudflareCookies = map[string]bool{
+		"__cf_bm": true, "__cflb": true, "__cfruid": true, "_cfuvid": true,
+		"cf_clearance": true, "__cfseq": true, "__cfwaitingroom": true,
+	}
+)
+
+// Baseline holds the signals extracted from the Cloudflare-fronted site that
+// candidates are compared against. Zero-valued fields are treated as missing
+// and left out of the score.
+type Baseline struct {
+	Domain     string
+	Title      string
+	StatusCode int
+	Simhash    uint64
+	Headers    []string
+	Cookies    []string
+	Redirect   string
+	// Favicon is the Shodan-style hash of the site's favicon.
+	Favicon string
+	// SPKI holds the public key fingerprints of the certificates Cloudflare
+	// serves for the target.
+	SPKI []string
+	// Challenge is set when Cloudflare answered with a challenge page instead
+	// of the site, in which case none of the page signals were kept.
+	Challenge bool
+}
+
+// Usable reports whether candidates can be verified against the baseline at
+// all, either through the page or through TLS certificate correlation.
+func (b *Baseline) Usable() bool {
+	return b.PageUsable() || (b != nil && len(b.SPKI) > 0)
+}
+
+// PageUsable reports whether the page carries at least one signal that can
+// identify the site on its own. Status codes, headers and cookies are only
+// supporting evidence; a baseline without a title, body or redirect target
+// would let any responding host match.
+func (b *Baseline) PageUsable() bool {
+	return b != nil && (b.Title != "" || b.Simhash != 0 || b.Redirect != "")
+}
+
+// Result is the outcome of comparing a candidate against a Baseline.
+type Result struct {
+	Score   int
+	Signals []string
+}
+
+// NewBaseline extracts the comparison signals for domain from page. title,
+// when not empty, overrides the page title.
+func NewBaseline(domain string, page *httpClient.Page, title string) *Baseline {
+	b := &Baseline{Domain: domain, Title: title}
+	if page == nil {
+		return b
+	}
+	if isChallenge(page) {
+		b.Challenge = true
+		return b
+	}
+	if b.Title == "" {
+		b.Title = page.Title
+	}
+	b.StatusCode = page.StatusCode
+	b.Redirect = page.Redirect
+	b.Simhash = Simhash(page.Body)
+	b.Headers = distinctiveHeaders(page.Header)
+	b.Cookies = cookieNames(page.Header)
+	return b
+}
+
+// Score compares page against the baseline and returns a 0-100 confidence
+// together with the signals that matched.
+func Score(b *Baseline, page *httpClient.Page) Result {
+	var result Result
+	if !b.PageUsable() || page == nil {
+		return result
+	}
+
+	var total, matched float64
+	add := func(signal string, weight int, similarity float64) {
+		total += float64(weight)
+		if similarity > 0 {
+			matched += float64(weight) * similarity
+			result.Signals = append(result.Signals, signal)
+		}
+	}
+
+	if b.Title != "" {
+		weight := weightTitle
+		if genericTitles[normalizeTitle(b.Title)] {
+			weight = weightGenericTitle
+		}
+		add(SignalTitle, weight, titleSimilarity(b.Title, page.Title))
+	}
+	if b.StatusCode != 0 {
+		var similarity float64
+		if b.StatusCode == page.StatusCode {
+			similarity = 1
+		}
+		add(SignalStatus, weightStatus, similarity)
+	}
+	if b.Simhash != 0 {
+		var similarity float64
+		if h := Simhash(page.Body); h != 0 {
+			if d := HammingDistance(b.Simhash, h); d <= maxBodyDistance {
+				similarity = 1 - float64(d)/float64(maxBodyDistance+1)
+			}
+		}
+		add(SignalBody, weightBody, similarity)
+	}
+	if len(b.Headers) > 0 {
+		add(SignalHeaders, weightHeaders, overlap(b.Headers, distinctiveHeaders(page.Header)))
+	}
+	if len(b.Cookies) > 0 {
+		add(SignalCookies, weightCookies, overlap(b.Cookies, cookieNames(page.Header)))
+	}
+	if b.Redirect != "" {
+		var similarity float64
+		if sameRedirect(b.Redirect, page.Redirect) {
+			similarity = 1
+		}
+		add(SignalRedirect, weightRedirect, similarity)
+	}
+	if b.Favicon != "" {
+		var similarity float64
+		if b.Favicon == page.Favicon {
+			similarity = 1
+		}
+		add(SignalFavicon, weightFavicon, similarity)
+	}
+
+	if total > 0 {
+		result.Score = int(matched/total*100 + 0.5)
+	}
+	return result
+}
+
+// isChallenge reports whether page is a Cloudflare challenge or block page
+// rather than the site itself.
+func isChallenge(page *httpClient.Page) bool {
+	if page.Header.Get("Cf-Mitigated") == "challenge" {
+		return true
+	}
+	title := normalizeTitle(page.Title)
+	return title == "just a moment..." || strings.HasPrefix(title, "attention required! | cloudflare")
+}
+
+// sameRedirect reports whether two Location values point to the same place.
+// Relative locations only compare by path and query, since the candidate was
+// requested by IP; absolute ones must also agree on the host.
+func sameRedirect(a, b string) bool {
+	ua, err := neturl.Parse(a)
+	if err != nil || b == "" {
+		return false
+	}
+	ub, err := neturl.Parse(b)
+	if err != nil {
+		return false
+	}
+	if ua.Host != "" && ub.Host != "" && !strings.EqualFold(ua.Hostname(), ub.Hostname()) {
+		return false
+	}
+	return strings.TrimSuffix(ua.Path, "/") == strings.TrimSuffix(ub.Path, "/") && ua.RawQuery == ub.RawQuery
+}
+
+// normalizeTitle lowercases a title, collapses whitespace and masks numbers
+// and long random-looking tokens so timestamps and nonces do not break a match.
+func normalizeTitle(title string) string {
+	title = strings.TrimSpace(strings.ToLower(title))
+	title = volatileToken.ReplaceAllString(title, "#")
+	return whitespace.ReplaceAllString(title, " ")
+}
+
+// titleSimilarity returns 1 for titles that are equal after normalization and
+// the word overlap otherwise.
+func titleSimilarity(a, b string) float64 {
+	na, nb := normalizeTitle(a), normalizeTitle(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	similarity := overlap(strings.Fields(na), strings.Fields(nb))
+	if similarity < 0.8 {
+		return 0
+	}
+	return similarity
+}
+
+// overlap returns the Jaccard index of two string sets.
+func overlap(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	set := make(map[string]bool, len(a))
+	for _, v := range a {
+		set[v] = true
+	}
+	var common int
+	union := len(set)
+	seen := make(map[string]bool, len(b))
+	for _, v := range b {
+		if seen[v] {
+			continue
+		}
+		seen[v] = true
+		if set[v] {
+			common++
+		} else {
+			union++
+		}
+	}
+	return float64(common) / float64(union)
+}
+
+// distinctiveHeaders returns the sorted names of response headers that are
+// likely specific to the application, e.g. X-Powered-By or custom X-* headers.
+func distinctiveHeaders(header http.Header) []string {
+	var names []string
+	for name := range header {
+		name = http.CanonicalHeaderKey(name)
+		if commonHeaders[name] || strings.HasPrefix(name, "Cf-") {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// cookieNames returns the sorted names of the cookies set by the response,
+// leaving out the ones Cloudflare sets itself.
+func cookieNames(header http.Header) []string {
+	var names []string
+	for _, cookie := range (&http.Response{Header: header}).Cookies() {
+		if cloudflareCookies[cookie.Name] {
+			continue
+		}
+		names = append(names, cookie.Name)
+	}
+	sort.Strings(names)
+	return names
+}
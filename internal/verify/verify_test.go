@@ -0,0 +1,189 @@
+package verify
+
+import (
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	httpClient "github.com/musana/cf-hero/internal/http"
+)
+
+const storeBody = `<html><head><title>Acme Store</title><script>var nonce = "a1b2c3";</script></head>
+<body><h1>Welcome to the Acme Store</h1>
+<p>Browse our catalogue of anvils, rockets, giant magnets and portable holes.
+Free shipping on orders over fifty dollars, returns accepted within thirty days.</p>
+<a href="/catalogue">Catalogue</a> <a href="/cart">Cart</a> <a href="/account">Account</a>
+<footer>Acme Corporation, makers of fine products since the beginning of time.</footer>
+</body></html>`
+
+const blogBody = `<html><head><title>Acme Store</title></head>
+<body><article><h2>Release notes</h2><p>This week we shipped a faster build pipeline,
+dark mode for the dashboard and a brand new command line client written in Go.</p>
+<a href="/blog/feed.xml">Feed</a></article></body></html>`
+
+func newPage(title string, status int, body string, header http.Header) *httpClient.Page {
+	if header == nil {
+		header = http.Header{}
+	}
+	return &httpClient.Page{Title: title, StatusCode: status, Body: []byte(body), Header: header}
+}
+
+func TestScore(t *testing.T) {
+	storeHeader := http.Header{
+		"X-Powered-By": {"PHP/8.2"},
+		"Set-Cookie":   {"shop_session=abc; Path=/", "__cf_bm=xyz; Path=/"},
+		"Server":       {"cloudflare"},
+	}
+	store := NewBaseline("shop.example.com", newPage("Acme Store", 200, storeBody, storeHeader), "")
+
+	tests := []struct {
+		name        string
+		baseline    *Baseline
+		page        *httpClient.Page
+		wantScore   int
+		wantSignals []string
+	}{
+		{
+			name:        "identical page",
+			baseline:    store,
+			page:        newPage("Acme Store", 200, storeBody, storeHeader),
+			wantScore:   100,
+			wantSignals: []string{SignalTitle, SignalStatus, SignalBody, SignalHeaders, SignalCookies},
+		},
+		{
+			name:        "nonce in script ignored",
+			baseline:    store,
+			page:        newPage("Acme Store", 200, strings.Replace(storeBody, "a1b2c3", "f9e8d7", 1), storeHeader),
+			wantScore:   100,
+			wantSignals: []string{SignalTitle, SignalStatus, SignalBody, SignalHeaders, SignalCookies},
+		},
+		{
+			name:        "same title, other page",
+			baseline:    store,
+			page:        newPage("Acme Store", 200, blogBody, nil),
+			wantScore:   45,
+			wantSignals: []string{SignalTitle, SignalStatus},
+		},
+		{
+			name:        "numbers in title masked",
+			baseline:    &Baseline{Title: "Acme Store 2023"},
+			page:        newPage("acme  store 2024", 200, "", nil),
+			wantScore:   100,
+			wantSignals: []string{SignalTitle},
+		},
+		{
+			name:     "different title",
+			baseline: &Baseline{Title: "Acme Store", StatusCode: 200},
+			page:     newPage("Default Web Site Page", 200, "", nil),
+			// The status alone carries 10 of the 45 points.
+			wantScore:   22,
+			wantSignals: []string{SignalStatus},
+		},
+		{
+			name:        "generic title weighs less",
+			baseline:    &Baseline{Title: "Login", StatusCode: 200, Redirect: "/login"},
+			page:        newPage("Login", 404, "", nil),
+			wantScore:   20,
+			wantSignals: []string{SignalTitle},
+		},
+		{
+			name:        "relative redirect matches absolute one",
+			baseline:    &Baseline{Redirect: "/login/?next=%2F"},
+			page:        &httpClient.Page{Redirect: "https://shop.example.com/login?next=%2F"},
+			wantScore:   100,
+			wantSignals: []string{SignalRedirect},
+		},
+		{
+			name:      "redirect to another host",
+			baseline:  &Baseline{Redirect: "https://shop.example.com/login"},
+			page:      &httpClient.Page{Redirect: "https://parked.example.net/login"},
+			wantScore: 0,
+		},
+		{
+			name:        "favicon",
+			baseline:    &Baseline{Title: "Acme Store", Favicon: "-123456"},
+			page:        &httpClient.Page{Title: "Other", Favicon: "-123456"},
+			wantScore:   36,
+			wantSignals: []string{SignalFavicon},
+		},
+		{
+			name:      "no page",
+			baseline:  store,
+			page:      nil,
+			wantScore: 0,
+		},
+		{
+			name:      "baseline without identifying signal",
+			baseline:  &Baseline{StatusCode: 200, Headers: []string{"X-Powered-By"}},
+			page:      newPage("", 200, "", http.Header{"X-Powered-By": {"PHP"}}),
+			wantScore: 0,
+		},
+		{
+			name:      "challenge baseline",
+			baseline:  NewBaseline("shop.example.com", newPage("Just a moment...", 403, "", nil), ""),
+			page:      newPage("Just a moment...", 403, "", nil),
+			wantScore: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Score(tt.baseline, tt.page)
+			if got.Score != tt.wantScore {
+				t.Errorf("Score = %d, want %d (signals %v)", got.Score, tt.wantScore, got.Signals)
+			}
+			if !reflect.DeepEqual(got.Signals, tt.wantSignals) {
+				t.Errorf("Signals = %v, want %v", got.Signals, tt.wantSignals)
+			}
+		})
+	}
+}
+
+func TestSimhash(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		close bool
+	}{
+		{"identical", storeBody, storeBody, true},
+		{"script contents ignored", storeBody, strings.Replace(storeBody, "a1b2c3", "zzzzzzzzzz", 1), true},
+		{"one word changed", storeBody, strings.Replace(storeBody, "fifty", "sixty", 1), true},
+		{"markup only changed", storeBody, strings.ReplaceAll(storeBody, "<p>", `<p class="lead">`), true},
+		{"case ignored", storeBody, strings.ToUpper(storeBody), true},
+		{"different page", storeBody, blogBody, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := HammingDistance(Simhash([]byte(tt.a)), Simhash([]byte(tt.b)))
+			if (d <= maxBodyDistance) != tt.close {
+				t.Errorf("distance = %d, want close = %t", d, tt.close)
+			}
+		})
+	}
+}
+
+func TestSimhashEmpty(t *testing.T) {
+	for _, body := range []string{"", "<html><head><script>var a = 1;</script></head></html>"} {
+		if h := Simhash([]byte(body)); h != 0 {
+			t.Errorf("Simhash(%q) = %x, want 0", body, h)
+		}
+	}
+}
+
+func TestHammingDistance(t *testing.T) {
+	tests := []struct {
+		a, b uint64
+		want int
+	}{
+		{0, 0, 0},
+		{0, 1, 1},
+		{0xff, 0x0f, 4},
+		{0, ^uint64(0), 64},
+	}
+	for _, tt := range tests {
+		if got := HammingDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("HammingDistance(%x, %x) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
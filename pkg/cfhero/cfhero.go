@@ -0,0 +1,392 @@
+// Package cfhero finds the origin servers of Cloudflare-protected sites. It
+// is the library behind the cf-hero command: a Scanner runs the same
+// techniques and verification, but reports everything through typed events
+// and results instead of printing.
+package cfhero
+
+import (
+	"context"
+	"fmt"
+	"net"
+	neturl "net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/musana/cf-hero/internal/checkpoint"
+	"github.com/musana/cf-hero/internal/dns"
+	httpClient "github.com/musana/cf-hero/internal/http"
+	"github.com/musana/cf-hero/internal/ranges"
+	"github.com/musana/cf-hero/internal/scanner"
+	"github.com/musana/cf-hero/internal/sources"
+	"github.com/musana/cf-hero/internal/target"
+	"github.com/musana/cf-hero/internal/verify"
+	"github.com/musana/cf-hero/pkg/models"
+)
+
+type (
+	Candidate    = models.Candidate
+	Finding      = models.Finding
+	Event        = models.Event
+	EventType    = models.EventType
+	Level        = models.Level
+	Summary      = models.Summary
+	Stats        = models.Stats
+	ZoneTransfer = models.ZoneTransfer
+)
+
+const (
+	EventLog                 = models.EventLog
+	EventTargetResolved      = models.EventTargetResolved
+	EventCloudflareStatus    = models.EventCloudflareStatus
+	EventSourceStarted       = models.EventSourceStarted
+	EventSourceFinished      = models.EventSourceFinished
+	EventCandidateDiscovered = models.EventCandidateDiscovered
+	EventCandidateVerified   = models.EventCandidateVerified
+	EventCandidateRejected   = models.EventCandidateRejected
+	EventFinding             = models.EventFinding
+	EventSummary             = models.EventSummary
+)
+
+const (
+	LevelInfo    = models.LevelInfo
+	LevelNotice  = models.LevelNotice
+	LevelSuccess = models.LevelSuccess
+	LevelWarning = models.LevelWarning
+	LevelError   = models.LevelError
+)
+
+// Defaults used for zero-valued options.
+const (
+	DefaultWorkers      = 16
+	DefaultMaxNeighbors = 256
+	DefaultFaviconPages = sources.DefaultFaviconPages
+	DefaultUserAgent    = "Mozilla/5.0 (Macintosh; Intel Mac OS X 10.15; rv:109.0) Gecko/20100101 Firefox/113.0"
+)
+
+// DefaultThreshold is the minimum score a candidate needs to be reported.
+const DefaultThreshold = verify.DefaultThreshold
+
+// summaryTimeout is how long Stream waits for the summary of an interrupted
+// scan to be received before dropping it.
+const summaryTimeout = time.Second
+
+// Options configures a Scanner. The zero value runs the DNS techniques with
+// the default settings.
+type Options struct {
+	// Workers is the number of targets scanned concurrently.
+	Workers int
+	// Verbose adds a log event for every candidate and every skipped step.
+	Verbose bool
+
+	// Sources lists the IDs of the passive sources to query: "censys",
+	// "securitytrails", "shodan" and "zoomeye".
+	Sources []string
+	// APIKeys holds the keys of each source, by ID.
+	APIKeys map[string][]string
+	// Favicon searches the sources for hosts serving the target's favicon,
+	// fetching at most FaviconPages result pages from each.
+	Favicon      bool
+	FaviconPages int
+	// Authoritative queries the zone's nameservers directly.
+	Authoritative bool
+	// Brute resolves the labels of Wordlist under every target. When it is
+	// empty, the labels are read from WordlistFile, one per line, or taken
+	// from the built-in list.
+	Brute        bool
+	Wordlist     []string
+	WordlistFile string
+	// SMTPBanner records the SMTP greeting of mail exchangers as evidence.
+	SMTPBanner bool
+	// SPFExpand is the largest SPF ip4/ip6 block, in addresses, expanded
+	// into individual candidates.
+	SPFExpand int
+	// Candidates are verified against every target.
+	Candidates []Candidate
+	// Domains are hosts whose non-Cloudflare addresses are verified against
+	// every target.
+	Domains []string
+	// Neighbors is the IPv4 prefix length around each confirmed origin
+	// whose addresses are verified too, at most MaxNeighbors per origin.
+	Neighbors    int
+	MaxNeighbors int
+
+	// Ports lists the candidate ports to probe, each optionally prefixed
+	// with its scheme ("https:8443"). CertPorts are additional TLS ports
+	// whose certificates are compared.
+	Ports     []string
+	CertPorts []string
+	// Threshold is the minimum score, out of 100, of a finding.
+	Threshold int
+	// Title replaces the page fetched through Cloudflare as the baseline,
+	// for sites that answer with a challenge.
+	Title      string
+	HTTPMethod string
+	UserAgent  string
+	// JA3 is the TLS fingerprint used for HTTPS requests.
+	JA3   string
+	Proxy string
+
+	// Resolvers are the DNS resolvers to use, in any form accepted by the
+	// -resolvers flag, along with those listed in ResolversFile, one per
+	// line. DNSRetries and DNSTimeout tune every lookup.
+	Resolvers     []string
+	ResolversFile string
+	DNSRetries    int
+	DNSTimeout    time.Duration
+	// RangesURL is where Cloudflare's IP lists are downloaded from.
+	RangesURL string
+
+	// Timeout bounds each scan and TargetTimeout the scan of each target.
+	// Zero means no limit.
+	Timeout       time.Duration
+	TargetTimeout time.Duration
+	// Resume is a checkpoint file recording the progress of the scan. When
+	// it exists, the targets and techniques it records as complete are
+	// skipped and the source responses it holds are reused.
+	Resume string
+
+	// OnEvent, when set, receives every event as it happens. Calls are
+	// serialized. More callbacks can be added with Subscribe.
+	OnEvent func(Event)
+}
+
+// Result is the outcome of a scan.
+type Result struct {
+	Findings []Finding
+	Summary  Summary
+}
+
+// Scanner discovers the origins of Cloudflare-protected targets.
+//
+// DNS and IP range settings are process-wide: creating a Scanner applies its
+// resolvers and ranges URL to every Scanner in the process.
+type Scanner struct {
+	options  Options
+	models   models.Options
+	progress *checkpoint.Checkpoint
+
+	eventMu  sync.Mutex
+	handlers []func(Event)
+}
+
+// New validates options and returns a Scanner.
+func New(options Options) (*Scanner, error) {
+	if options.Workers <= 0 {
+		options.Workers = DefaultWorkers
+	}
+	if options.Threshold <= 0 {
+		options.Threshold = DefaultThreshold
+	}
+	if options.FaviconPages <= 0 {
+		options.FaviconPages = DefaultFaviconPages
+	}
+	if options.MaxNeighbors <= 0 {
+		options.MaxNeighbors = DefaultMaxNeighbors
+	}
+	if options.HTTPMethod == "" {
+		options.HTTPMethod = "GET"
+	}
+	if options.UserAgent == "" {
+		options.UserAgent = DefaultUserAgent
+	}
+	if len(options.Ports) == 0 {
+		options.Ports = httpClient.DefaultPorts
+	}
+	if options.Brute && len(options.Wordlist) == 0 {
+		words, err := dns.LoadWordlist(options.WordlistFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not load wordlist: %v", err)
+		}
+		options.Wordlist = words
+	}
+	if options.ResolversFile != "" {
+		list, err := dns.ReadResolverFile(options.ResolversFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read resolvers file: %v", err)
+		}
+		options.Resolvers = append(append([]string{}, options.Resolvers...), list...)
+	}
+
+	if _, err := httpClient.ParseEndpoints(options.Ports); err != nil {
+		return nil, fmt.Errorf("invalid ports: %v", err)
+	}
+	if options.Timeout < 0 || options.TargetTimeout < 0 {
+		return nil, fmt.Errorf("timeouts cannot be negative")
+	}
+	if options.Neighbors < 0 || options.Neighbors > 31 {
+		return nil, fmt.Errorf("invalid neighbors prefix length %d", options.Neighbors)
+	}
+	if options.Proxy != "" {
+		if _, err := neturl.Parse(options.Proxy); err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %v", err)
+		}
+	}
+	var progress *checkpoint.Checkpoint
+	if options.Resume != "" {
+		var err error
+		if progress, err = checkpoint.Open(options.Resume); err != nil {
+			return nil, err
+		}
+	}
+	if err := dns.Configure(options.Resolvers, options.DNSRetries, options.DNSTimeout); err != nil {
+		return nil, err
+	}
+	ranges.Configure(options.RangesURL, options.Proxy)
+
+	return &Scanner{
+		options:  options,
+		progress: progress,
+		models: models.Options{
+			Worker:        options.Workers,
+			Verbose:       options.Verbose,
+			Sources:       options.Sources,
+			Favicon:       options.Favicon,
+			FaviconPages:  options.FaviconPages,
+			Authoritative: options.Authoritative,
+			Brute:         options.Brute,
+			SMTPBanner:    options.SMTPBanner,
+			SPFExpand:     options.SPFExpand,
+			Neighbors:     options.Neighbors,
+			MaxNeighbors:  options.MaxNeighbors,
+			Ports:         options.Ports,
+			CertPorts:     options.CertPorts,
+			Threshold:     options.Threshold,
+			Title:         options.Title,
+			HTTPMethod:    options.HTTPMethod,
+			UserAgent:     options.UserAgent,
+			JA3:           options.JA3,
+			Proxy:         options.Proxy,
+			Timeout:       options.Timeout,
+			TargetTimeout: options.TargetTimeout,
+		},
+	}, nil
+}
+
+// Subscribe registers fn to receive every event of the scans started
+// afterwards, in addition to Options.OnEvent. Calls to every callback are
+// serialized.
+func (s *Scanner) Subscribe(fn func(Event)) {
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+	s.handlers = append(s.handlers, fn)
+}
+
+// Scan looks for the origins of targets, given as URLs or bare hostnames, and
+// returns the findings along with the scan's statistics. When ctx is done or
+// Options.Timeout expires, no new work is started, the work in progress is
+// cut short, and the partial result is returned, marked as interrupted, with
+// the context's error.
+func (s *Scanner) Scan(ctx context.Context, targets []string) (*Result, error) {
+	return s.scan(ctx, targets, nil)
+}
+
+// Stream runs a scan in the background and delivers its events on the
+// returned channel, which is closed once the scan is over. The last event is
+// the summary. The scan waits for every event to be received until ctx is
+// done; after that, events nobody receives are dropped, so a receiver may
+// stop reading once it cancels ctx. The summary of an interrupted scan is
+// still delivered to a receiver that keeps reading.
+func (s *Scanner) Stream(ctx context.Context, targets []string) <-chan Event {
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+		s.scan(ctx, targets, func(event Event) {
+			select {
+			case events <- event:
+				return
+			case <-ctx.Done():
+			}
+			if event.Type == EventSummary {
+				timer := time.NewTimer(summaryTimeout)
+				defer timer.Stop()
+				select {
+				case events <- event:
+				case <-timer.C:
+				}
+			}
+		})
+	}()
+	return events
+}
+
+// scan runs a scan, handing its events to sink as well as to the registered
+// callbacks.
+func (s *Scanner) scan(ctx context.Context, targets []string, sink func(Event)) (*Result, error) {
+	if s.options.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, s.options.Timeout)
+		defer cancel()
+	}
+
+	result := &Result{}
+	var mu sync.Mutex
+	emit := func(event Event) {
+		if event.Type == EventFinding && event.Finding != nil {
+			mu.Lock()
+			result.Findings = append(result.Findings, *event.Finding)
+			mu.Unlock()
+		}
+		s.publish(event, sink)
+	}
+
+	options := s.models
+	sc := scanner.New(&options, s.apiKeys, emit)
+	sc.Wordlist = s.options.Wordlist
+	sc.Candidates = s.options.Candidates
+	sc.Checkpoint = s.progress
+	defer s.progress.Close()
+	if s.progress.Resumed() {
+		emit(Event{Type: EventLog, Level: LevelNotice, Message: fmt.Sprintf("[*] Resuming the scan from %s", s.options.Resume), Time: time.Now().UTC()})
+	}
+	sc.AddDomains(s.options.Domains)
+
+	if _, err := ranges.Load(); err != nil {
+		message := err.Error()
+		message = "[!] " + strings.ToUpper(message[:1]) + message[1:] + "."
+		emit(Event{Type: EventLog, Level: LevelWarning, Message: message, Time: time.Now().UTC()})
+	}
+
+	sc.AddTargets(targets)
+	sc.Run(ctx)
+	result.Summary = sc.Summary()
+	result.Summary.Interrupted = ctx.Err() != nil
+	summary := result.Summary
+	emit(Event{Type: EventSummary, Summary: &summary, Time: time.Now().UTC()})
+	return result, ctx.Err()
+}
+
+// Resumed reports whether Options.Resume names a checkpoint holding the
+// progress of a previous run.
+func (s *Scanner) Resumed() bool {
+	return s.progress.Resumed()
+}
+
+// Lookup resolves the host of target and splits its addresses into those in
+// Cloudflare's ranges and the others.
+func (s *Scanner) Lookup(ctx context.Context, input string) (cloudflare, other []net.IP, err error) {
+	t, err := target.Parse(input)
+	if err != nil {
+		return nil, nil, err
+	}
+	cloudflare, other = dns.GetARecords(ctx, t.Host)
+	return cloudflare, other, ctx.Err()
+}
+
+func (s *Scanner) apiKeys(id string) []string {
+	return s.options.APIKeys[id]
+}
+
+func (s *Scanner) publish(event Event, sink func(Event)) {
+	s.eventMu.Lock()
+	defer s.eventMu.Unlock()
+	if s.options.OnEvent != nil {
+		s.options.OnEvent(event)
+	}
+	for _, fn := range s.handlers {
+		fn(event)
+	}
+	if sink != nil {
+		sink(event)
+	}
+}
@@ -0,0 +1,92 @@
+package cfhero
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestScanner returns a Scanner whose range refresh fails locally, so the
+// built-in list is used without network access.
+func newTestScanner(t *testing.T, options Options) *Scanner {
+	t.Helper()
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	ranges := httptest.NewServer(http.NotFoundHandler())
+	t.Cleanup(ranges.Close)
+	options.RangesURL = ranges.URL
+	s, err := New(options)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestStreamAbandoned(t *testing.T) {
+	published := make(chan struct{}, 1)
+	s := newTestScanner(t, Options{OnEvent: func(Event) {
+		select {
+		case published <- struct{}{}:
+		default:
+		}
+	}})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// Nobody reads the events of a cancelled stream.
+	s.Stream(ctx, []string{"example.com"})
+	<-published
+
+	done := make(chan struct{})
+	go func() {
+		s.Subscribe(func(Event) {})
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("Subscribe blocked behind an abandoned stream")
+	}
+}
+
+func TestStreamSummaryAfterCancel(t *testing.T) {
+	s := newTestScanner(t, Options{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	var last Event
+	for event := range s.Stream(ctx, []string{"example.com"}) {
+		last = event
+	}
+	if last.Type != EventSummary || last.Summary == nil || !last.Summary.Interrupted {
+		t.Errorf("last event = %+v, want the summary of an interrupted scan", last)
+	}
+}
+
+func TestParseCandidates(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   []string
+		max     int
+		want    int
+		wantErr error
+	}{
+		{"addresses and blocks", []string{"# origins", "192.0.2.1", "", "198.51.100.0/30", "192.0.2.1"}, 0, 5, nil},
+		{"over the limit", []string{"198.51.100.0/24"}, 10, 10, ErrCandidateLimit},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseCandidates(tt.lines, tt.max)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("got %d candidates, want %d", len(got), tt.want)
+			}
+		})
+	}
+	if _, err := ParseCandidates([]string{"not-an-ip"}, 0); err == nil {
+		t.Error("ParseCandidates accepted an invalid line")
+	}
+}
@@ -0,0 +1,63 @@
+package cfhero
+
+import (
+	"github.com/musana/cf-hero/internal/candidates"
+	"github.com/musana/cf-hero/internal/ranges"
+)
+
+// DefaultMaxCandidates is the number of addresses a candidate list may
+// expand to unless configured otherwise.
+const DefaultMaxCandidates = candidates.DefaultLimit
+
+// ErrCandidateLimit is returned along with the first addresses of a candidate
+// list that expands to more than the limit.
+var ErrCandidateLimit = candidates.ErrLimit
+
+// ParseCandidates parses a list of IP addresses and CIDR blocks, one per
+// line, into candidates for Options.Candidates. Blank lines and lines
+// starting with '#' are skipped. At most max addresses are returned, or
+// DefaultMaxCandidates when max is zero; when the list covers more, those
+// found so far are returned along with ErrCandidateLimit.
+func ParseCandidates(lines []string, max int) ([]Candidate, error) {
+	if max <= 0 {
+		max = DefaultMaxCandidates
+	}
+	return candidates.Expand(lines, max)
+}
+
+// ReadCandidates parses the candidate list in the file at path, or on stdin
+// when path is "-", as ParseCandidates does.
+func ReadCandidates(path string, max int) ([]Candidate, error) {
+	lines, err := candidates.Read(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCandidates(lines, max)
+}
+
+// ImportScan reads an nmap XML (-oX) or masscan JSON (-oJ) report and returns
+// every host with open TCP ports as a candidate. Only those ports are probed
+// when the candidate is verified.
+func ImportScan(path string) ([]Candidate, error) {
+	return candidates.Import(path)
+}
+
+// Ranges are Cloudflare's IP ranges.
+type Ranges struct {
+	IPv4 []string
+	IPv6 []string
+	// CachePath is the file every later scan loads them from.
+	CachePath string
+}
+
+// UpdateRanges downloads Cloudflare's IP lists from url, or from the default
+// location when it is empty, through proxy when set, and caches them for the
+// scans that follow.
+func UpdateRanges(url, proxy string) (*Ranges, error) {
+	ranges.Configure(url, proxy)
+	set, err := ranges.Update()
+	if err != nil {
+		return nil, err
+	}
+	return &Ranges{IPv4: set.IPv4, IPv6: set.IPv6, CachePath: ranges.CachePath()}, nil
+}
@@ -2,26 +2,224 @@ package models
 
 import (
 	"encoding/json"
+	"net"
+	"time"
 )
 
 type Options struct {
-	File           string
-	Worker         int
-	Version        bool
-	HTTPMethod     string
-	UserAgent      string
-	Proxy          string
-	DomainList     string
-	TargetDomain   string
-	JA3            string
-	CF             bool
-	NCF            bool
-	Censys         bool
-	SecurityTrails bool
-	Shodan         bool
-	Zoomeye        bool
-	Verbose        bool
-	Title          string
+	File         string
+	Worker       int
+	Version      bool
+	HTTPMethod   string
+	UserAgent    string
+	Proxy        string
+	DomainList   string
+	TargetDomain string
+	JA3          string
+	CF           bool
+	NCF          bool
+	// Sources holds the IDs of the enabled passive discovery sources.
+	Sources   []string
+	Verbose   bool
+	Title     string
+	Threshold int
+	CertPorts []string
+	// Authoritative enables querying the zone's nameservers directly.
+	Authoritative bool
+	// Brute enables subdomain brute force with Wordlist, or the built-in
+	// list when Wordlist is empty.
+	Brute    bool
+	Wordlist string
+	// SMTPBanner enables reading the SMTP greeting of mail exchangers.
+	SMTPBanner bool
+	// SPFExpand is the largest SPF ip4/ip6 block, in addresses, expanded
+	// into individual candidates.
+	SPFExpand int
+	// Candidates is a file ("-" for stdin) of IPs and CIDR blocks verified
+	// against every target, expanded to at most MaxCandidates addresses.
+	Candidates    string
+	MaxCandidates int
+	// Import is an nmap XML or masscan JSON report whose open ports are
+	// verified against every target.
+	Import string
+	// Neighbors is the IPv4 prefix length around each confirmed origin
+	// whose addresses are verified too, at most MaxNeighbors per origin.
+	// Zero disables the expansion.
+	Neighbors    int
+	MaxNeighbors int
+	// Ports lists the candidate ports to probe, each optionally prefixed
+	// with its scheme ("https:8443").
+	Ports   []string
+	Favicon bool
+	// FaviconPages caps the result pages each source fetches for a favicon
+	// search.
+	FaviconPages int
+	JSON         bool
+	Output       string
+	// Resolvers and ResolversFile override the DNS resolvers; DNSRetries
+	// and DNSTimeout (seconds) tune every lookup.
+	Resolvers     []string
+	ResolversFile string
+	DNSRetries    int
+	DNSTimeout    int
+	RangesURL     string
+	UpdateRanges  bool
+	// Timeout bounds the whole scan and TargetTimeout the scan of each
+	// target. Zero means no limit.
+	Timeout       time.Duration
+	TargetTimeout time.Duration
+	// Resume is a checkpoint file that records the progress of the scan and
+	// lets a restarted run skip the work already done.
+	Resume string
+}
+
+// Candidate is a potential origin IP reported by a discovery source.
+type Candidate struct {
+	IP     net.IP
+	Source string
+	// Ports are ports the source saw open on the address. They are probed
+	// in addition to the -ports list.
+	Ports []int
+	// Detail is free-form context shown in verbose output, e.g. when the
+	// address was last seen.
+	Detail string
+	// Evidence holds observations that support the candidate without
+	// being scored, such as the hostname in its SMTP banner. It is copied
+	// to the finding.
+	Evidence []string
+	// PortsOpen reports that Ports are the ports known to be open on the
+	// address, e.g. from a port scan. Only they are probed, without a
+	// reachability check.
+	PortsOpen bool
+}
+
+// Finding is a confirmed origin IP of a Cloudflare-protected target.
+type Finding struct {
+	Type         string    `json:"type"`
+	URL          string    `json:"url"`
+	CloudflareIP string    `json:"cloudflare_ip"`
+	OriginIP     string    `json:"origin_ip"`
+	Source       string    `json:"source"`
+	Port         int       `json:"port,omitempty"`
+	Scheme       string    `json:"scheme,omitempty"`
+	Title        string    `json:"title,omitempty"`
+	Score        int       `json:"score"`
+	Signals      []string  `json:"signals"`
+	Evidence     []string  `json:"evidence,omitempty"`
+	Timestamp    time.Time `json:"timestamp"`
+}
+
+// Stats holds the counters collected over a scan.
+type Stats struct {
+	Total           int `json:"total"`
+	Behind          int `json:"behind_cloudflare"`
+	NotBehind       int `json:"not_behind_cloudflare"`
+	TotalIPsScanned int `json:"ips_scanned"`
+	RealIPsFound    int `json:"real_ips_found"`
+	Unverifiable    int `json:"unverifiable"`
+	AXFRAttempted   int `json:"axfr_attempted"`
+	AXFRSucceeded   int `json:"axfr_succeeded"`
+	DNSCacheHits    int `json:"dns_cache_hits"`
+	DNSCacheMisses  int `json:"dns_cache_misses"`
+	// TimedOut counts the targets cut short by the per-target timeout.
+	TimedOut int `json:"timed_out"`
+}
+
+// Level is the severity of a log event. The CLI prints each level in its own
+// colour.
+type Level int
+
+const (
+	LevelInfo Level = iota
+	LevelNotice
+	LevelSuccess
+	LevelWarning
+	LevelError
+)
+
+// EventType identifies what an Event reports.
+type EventType string
+
+const (
+	// EventLog carries a human-readable line.
+	EventLog EventType = "log"
+	// EventTargetResolved carries the addresses a target resolved to.
+	EventTargetResolved EventType = "target_resolved"
+	// EventCloudflareStatus reports whether a target is behind Cloudflare.
+	EventCloudflareStatus EventType = "cloudflare_status"
+	// EventSourceStarted and EventSourceFinished frame the run of a
+	// technique or passive source against a target.
+	EventSourceStarted  EventType = "source_started"
+	EventSourceFinished EventType = "source_finished"
+	// EventCandidateDiscovered carries a candidate as a source produced it.
+	EventCandidateDiscovered EventType = "candidate_discovered"
+	// EventCandidateVerified and EventCandidateRejected carry the outcome
+	// of verifying a candidate against a target.
+	EventCandidateVerified EventType = "candidate_verified"
+	EventCandidateRejected EventType = "candidate_rejected"
+	// EventFinding carries a confirmed origin.
+	EventFinding EventType = "finding"
+	// EventSummary carries the statistics of a finished scan.
+	EventSummary EventType = "summary"
+)
+
+// Event is published while a scan runs. Which fields are set depends on its
+// type.
+type Event struct {
+	Type EventType `json:"type"`
+	// Level and Message describe a log event. Message is a human-readable
+	// line, formatted as the CLI prints it.
+	Level   Level  `json:"level,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Target is the target the event concerns, as it was given, and URL the
+	// address its candidates are verified against.
+	Target string `json:"target,omitempty"`
+	URL    string `json:"url,omitempty"`
+	// Source is the technique or passive source of source and candidate
+	// events.
+	Source string `json:"source,omitempty"`
+	// IPs are the addresses of the target on target_resolved events.
+	IPs []net.IP `json:"ips,omitempty"`
+	// Cloudflare reports whether the target, on cloudflare_status events,
+	// or the candidate, on candidate_discovered events, is in Cloudflare's
+	// ranges.
+	Cloudflare bool `json:"cloudflare"`
+	// Found counts the candidates of a source_finished event, and
+	// NonCloudflare those outside Cloudflare's ranges.
+	Found         int `json:"found,omitempty"`
+	NonCloudflare int `json:"non_cloudflare,omitempty"`
+	// Candidate is set on candidate events. Score and Signals are the
+	// outcome of its verification, and Reason why it was rejected.
+	Candidate *Candidate `json:"candidate,omitempty"`
+	Score     int        `json:"score,omitempty"`
+	Signals   []string   `json:"signals,omitempty"`
+	Reason    string     `json:"reason,omitempty"`
+	// Finding is set on finding events and Summary on summary events.
+	Finding *Finding  `json:"finding,omitempty"`
+	Summary *Summary  `json:"summary,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// ZoneTransfer records an AXFR attempt against one nameserver of a target's
+// zone.
+type ZoneTransfer struct {
+	Domain     string `json:"domain"`
+	Zone       string `json:"zone"`
+	Nameserver string `json:"nameserver"`
+	Success    bool   `json:"success"`
+	Records    int    `json:"records,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// Summary is emitted once at the end of a scan.
+type Summary struct {
+	Type string `json:"type"`
+	Stats
+	// Interrupted reports that the scan was stopped, by cancellation or by
+	// its timeout, before every target was scanned: the results are partial.
+	Interrupted   bool           `json:"interrupted,omitempty"`
+	ZoneTransfers []ZoneTransfer `json:"zone_transfers,omitempty"`
+	Timestamp     time.Time      `json:"timestamp"`
 }
 
 // CensysPlatformResponse models the Censys Platform API
@@ -46,8 +244,10 @@ type SecurityTrailsResponse struct {
 	Pages    int    `json:"pages"`
 	Records  []struct {
 		Values []struct {
-			IP      string `json:"ip"`
-			IPCount int    `json:"ip_count"`
+			IP        string `json:"ip"`
+			IPCount   int    `json:"ip_count"`
+			IPv6      string `json:"ipv6"`
+			IPv6Count int    `json:"ipv6_count"`
 		} `json:"values"`
 		Type          string   `json:"type"`
 		FirstSeen     string   `json:"first_seen"`
@@ -81,3 +281,14 @@ type ZoomeyeResponse struct {
 	} `json:"data"`
 	Facets map[string]interface{} `json:"facets"`
 }
+
+// ShodanHostSearchResponse models the Shodan host search API
+// (GET https://api.shodan.io/shodan/host/search) response.
+type ShodanHostSearchResponse struct {
+	Matches []struct {
+		IPStr     string   `json:"ip_str"`
+		Port      int      `json:"port"`
+		Hostnames []string `json:"hostnames"`
+	} `json:"matches"`
+	Total int `json:"total"`
+}